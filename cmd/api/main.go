@@ -5,13 +5,21 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/database"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/idempotency"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/jobs"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/metering"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/middleware"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/ratelimit"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/requestid"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/route"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -32,7 +40,15 @@ func main() {
 	defer closeDatabase(db)
 	rdb := setupRedis()
 	defer rdb.Close()
+	setupStorage()
+	// job berjalan di process yang menerima request, juga child Prefork
+	jobs.Bind(ctx)
 	setupRoutes(app, db, rdb)
+	// dengan Prefork cukup di parent process, bukan di setiap child
+	if !fiber.IsChild() {
+		route.Background(ctx, db, rdb)
+		go metering.FlushLoop(ctx, config.MeteringFlush)
+	}
 
 	address := fmt.Sprintf("%s:%d", config.AppHost, config.AppPort)
 
@@ -40,6 +56,10 @@ func main() {
 	serverErrors := make(chan error, 1)
 	go startServer(app, address, serverErrors)
 	handleGracefulShutdown(ctx, app, serverErrors)
+
+	// job yang masih jalan dibatalkan dan dicatat failed sebelum DB & Redis ditutup
+	cancel()
+	jobs.Wait()
 }
 
 func setupRedis() *redis.Client {
@@ -59,16 +79,33 @@ func setupRedis() *redis.Client {
 	return rdb
 }
 
+func setupStorage() {
+	if _, err := storage.Default(); err != nil {
+		utils.Log.Fatalf("Storage init failed: %v", err)
+	}
+}
+
 func setupFiberApp() *fiber.App {
+	// dipasang di sini, config tidak boleh bergantung pada response
+	response.ProblemDefault = config.ErrorFormat == "problem"
+	response.ProblemTypeBase = config.ProblemTypeBaseURL
+
 	app := fiber.New(config.FiberConfig())
 
 	// Middleware setup
+	app.Use(middleware.RequestID())
+	app.Use(middleware.BodyLimit(config.BodyLimit))
+	app.Use("/api", middleware.APILimiter())
 	app.Use("/api/auth", middleware.LimiterConfig())
 	app.Use(middleware.LoggerConfig())
 	app.Use(helmet.New())
 	app.Use(compress.New())
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		ExposeHeaders: strings.Join(append([]string{requestid.Header, idempotency.HeaderReplayed}, ratelimit.Headers...), ","),
+	}))
+	app.Use(middleware.Negotiate())
 	app.Use(middleware.RecoverConfig())
+	app.Use(middleware.ReadYourWrites())
 
 	return app
 }
@@ -109,15 +146,22 @@ func setupRoutes(app *fiber.App, db *gorm.DB, rdb *redis.Client) {
 			status = fiber.StatusServiceUnavailable
 			statusText = "degraded"
 		}
+		// replica down tidak bikin not-ready, read otomatis fallback ke primary
+		replicas := fiber.Map{}
+		for name, ok := range database.ReplicaStatus() {
+			replicas[name] = map[bool]string{true: "up", false: "down"}[ok]
+		}
+
 		body := fiber.Map{
-			"status": statusText,
-			"db":     map[bool]string{true: "up", false: "down"}[dbOK],
-			"redis":  map[bool]string{true: "up", false: "down"}[redisOK],
+			"status":   statusText,
+			"db":       map[bool]string{true: "up", false: "down"}[dbOK],
+			"redis":    map[bool]string{true: "up", false: "down"}[redisOK],
+			"replicas": replicas,
 		}
 		return c.Status(status).JSON(body)
 	})
 
-	route.Routes(app, db)
+	route.Routes(app, db, rdb)
 	app.Use(utils.NotFoundHandler)
 }
 
@@ -128,6 +172,8 @@ func startServer(app *fiber.App, address string, errs chan<- error) {
 }
 
 func closeDatabase(db *gorm.DB) {
+	database.CloseReplicas()
+
 	sqlDB, errDB := db.DB()
 	if errDB != nil {
 		utils.Log.Errorf("Error getting database instance: %v", errDB)
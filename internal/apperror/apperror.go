@@ -0,0 +1,173 @@
+package apperror
+
+import (
+	"errors"
+	"maps"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+
+	"gorm.io/gorm"
+)
+
+// Kind is the category of a domain error, it decides the HTTP status.
+type Kind int
+
+const (
+	Internal Kind = iota
+	BadRequest
+	Validation
+	Unauthorized
+	PaymentRequired
+	Forbidden
+	NotFound
+	Conflict
+	Gone
+	PreconditionFailed
+	TooLarge
+	UnsupportedMedia
+	Unprocessable
+	RateLimited
+	BadGateway
+	Unavailable
+)
+
+var kindTypes = map[Kind]response.ProblemType{
+	Internal:           response.ProblemInternal,
+	BadRequest:         response.ProblemBadRequest,
+	Validation:         response.ProblemValidation,
+	Unauthorized:       response.ProblemUnauthorized,
+	PaymentRequired:    response.ProblemPaymentRequired,
+	Forbidden:          response.ProblemForbidden,
+	NotFound:           response.ProblemNotFound,
+	Conflict:           response.ProblemConflict,
+	Gone:               response.ProblemGone,
+	PreconditionFailed: response.ProblemPreconditionFailed,
+	TooLarge:           response.ProblemPayloadTooLarge,
+	UnsupportedMedia:   response.ProblemUnsupportedMedia,
+	Unprocessable:      response.ProblemUnprocessable,
+	RateLimited:        response.ProblemTooManyRequests,
+	BadGateway:         response.ProblemBadGateway,
+	Unavailable:        response.ProblemUnavailable,
+}
+
+// Status returns the HTTP status of the kind.
+func (k Kind) Status() int {
+	return kindTypes[k].Status
+}
+
+// Error is a domain error. Services return it instead of HTTP errors,
+// utils.ErrorHandler turns it into the response.
+type Error struct {
+	Kind    Kind
+	Code    string         // registered problem code, ex: user_not_found
+	Message string         // safe to show to the client
+	Meta    map[string]any // extra problem members, ex: {"retry_after": 30}
+	Err     error          // cause, only logged
+
+	origin *Error // the catalogue entry e was copied from
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func (e *Error) ErrorCode() string { return e.Code }
+
+func (e *Error) Status() int { return e.Kind.Status() }
+
+// Is matches copies of the same catalogue entry, so errors.Is(err, ErrUserNotFound)
+// keeps working after With / Wrap / Msg.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.root() == e.root()
+}
+
+func (e *Error) root() *Error {
+	if e.origin != nil {
+		return e.origin
+	}
+	return e
+}
+
+// With returns a copy of e carrying key in its metadata.
+func (e *Error) With(key string, value any) *Error {
+	cp := *e
+	cp.origin = e.root()
+	cp.Meta = maps.Clone(e.Meta)
+	if cp.Meta == nil {
+		cp.Meta = map[string]any{}
+	}
+	cp.Meta[key] = value
+	return &cp
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *Error) Wrap(err error) *Error {
+	cp := *e
+	cp.origin = e.root()
+	cp.Err = err
+	return &cp
+}
+
+// Msg returns a copy of e with another client message, the code stays.
+func (e *Error) Msg(message string) *Error {
+	cp := *e
+	cp.origin = e.root()
+	cp.Message = message
+	return &cp
+}
+
+// Define registers code as a problem type and returns its error, declare the
+// catalogue of a module as package level vars. Codes are part of the API
+// contract, never rename one:
+//
+//	var ErrUserNotFound = apperror.Define(apperror.NotFound, "user_not_found", "User not found")
+func Define(kind Kind, code, message string) *Error {
+	response.RegisterProblem(response.ProblemType{Code: code, Status: kind.Status(), Title: message})
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// New returns an error with the generic code of kind, for one-off messages.
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Code: kindTypes[kind].Code, Message: message}
+}
+
+// generic catalogue, the first three are the targets of From
+var (
+	ErrNotFound  = New(NotFound, "Resource not found")
+	ErrDuplicate = Define(Conflict, "duplicate", "Resource already exists")
+	ErrReference = Define(Conflict, "reference_violation", "Resource is referenced by or references a missing record")
+
+	ErrUnauthenticated = Define(Unauthorized, "unauthenticated", "Please authenticate")
+	ErrForbidden       = New(Forbidden, "You don't have permission to access this resource")
+	ErrSuspended       = Define(Forbidden, "account_suspended", "Your account is suspended")
+)
+
+// From translates known infrastructure errors (GORM with TranslateError) to
+// domain errors, anything else is returned as is.
+func From(err error) error {
+	var appErr *Error
+	switch {
+	case err == nil, errors.As(err, &appErr):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound.Wrap(err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicate.Wrap(err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return ErrReference.Wrap(err)
+	}
+	return err
+}
+
+// As returns the domain error in err's chain.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	ok := errors.As(err, &appErr)
+	return appErr, ok
+}
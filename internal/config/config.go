@@ -1,7 +1,10 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
 
@@ -11,14 +14,21 @@ import (
 var (
 	IsProd              bool
 	AppHost             string
+	AppURL              string
 	Version             string
 	LogLevel            string
+	LogFormat           string
+	ErrorFormat         string // "problem" makes problem+json the default, see main
+	ProblemTypeBaseURL  string
 	AppPort             int
 	DBHost              string
 	DBUser              string
 	DBPassword          string
 	DBName              string
 	DBPort              int
+	DBReplicaDSNs       []string
+	DBStickyWindow      time.Duration
+	DBSlowQuery         time.Duration
 	JWTSecret           string
 	JWTAccessExp        int
 	JWTRefreshExp       int
@@ -26,7 +36,31 @@ var (
 	JWTVerifyEmailExp   int
 	PostgresDSN         string
 	RedisURL            string
+	CacheTTL            time.Duration
+	RateLimitRequests   int
+	RateLimitWindow     time.Duration
+	RateLimitFailOpen   bool
+	TrustedProxies      []string
+	ProxyHeader         string
+	Plans               map[string]Plan
+	DefaultPlan         string
+	MeteringFlush       time.Duration
+	IdempotencyTTL      time.Duration
 	Issuer              string
+	DeletionGrace       time.Duration
+	StorageDriver       string
+	StorageLocalRoot    string
+	StorageS3Endpoint   string
+	StorageS3AccessKey  string
+	StorageS3SecretKey  string
+	StorageS3Bucket     string
+	StorageS3Region     string
+	StorageS3UseSSL     bool
+	StorageSigningKey   string
+	StorageURLTTL       time.Duration
+	UploadMaxSize       int64
+	UploadAllowedTypes  []string
+	BodyLimit           int64
 	SMTPHost            string
 	SMTPPort            int
 	SMTPUsername        string
@@ -52,8 +86,27 @@ func init() {
 	if AppPort == 0 {
 		AppPort = 8080
 	}
+	AppURL = strings.TrimRight(viper.GetString("APP_URL"), "/")
+	if AppURL == "" {
+		AppURL = fmt.Sprintf("http://localhost:%d", AppPort)
+	}
 	Version = viper.GetString("VERSION")
 	LogLevel = viper.GetString("LOG_LEVEL")
+	LogFormat = viper.GetString("LOG_FORMAT")
+	if LogFormat == "" {
+		LogFormat = "text"
+		if IsProd {
+			LogFormat = "json"
+		}
+	}
+	utils.SetupLog(LogFormat, LogLevel)
+
+	// error format, clients can still ask for problem+json via Accept
+	ErrorFormat = viper.GetString("ERROR_FORMAT")
+	ProblemTypeBaseURL = viper.GetString("PROBLEM_TYPE_BASE_URL")
+	if ProblemTypeBaseURL == "" {
+		ProblemTypeBaseURL = AppURL + "/api/problems/"
+	}
 
 	// database configuration
 	DBHost = viper.GetString("DB_HOST")
@@ -68,6 +121,19 @@ func init() {
 			DBUser, DBPassword, DBHost, DBPort, DBName,
 		)
 	}
+	for _, dsn := range strings.Split(viper.GetString("DB_REPLICA_DSNS"), ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			DBReplicaDSNs = append(DBReplicaDSNs, dsn)
+		}
+	}
+	DBStickyWindow = time.Duration(viper.GetInt("DB_STICKY_WINDOW_SECONDS")) * time.Second
+	if DBStickyWindow <= 0 {
+		DBStickyWindow = 5 * time.Second
+	}
+	DBSlowQuery = time.Duration(viper.GetInt("DB_SLOW_QUERY_MS")) * time.Millisecond
+	if DBSlowQuery <= 0 {
+		DBSlowQuery = 200 * time.Millisecond
+	}
 
 	// jwt configuration
 	JWTSecret = viper.GetString("JWT_SECRET")
@@ -75,12 +141,110 @@ func init() {
 	JWTRefreshExp = viper.GetInt("JWT_REFRESH_EXP_DAYS")
 	JWTResetPasswordExp = viper.GetInt("JWT_RESET_PASSWORD_EXP_MINUTES")
 	JWTVerifyEmailExp = viper.GetInt("JWT_VERIFY_EMAIL_EXP_MINUTES")
+	if JWTVerifyEmailExp <= 0 {
+		JWTVerifyEmailExp = 10
+	}
+
+	// account self-deletion
+	DeletionGrace = time.Duration(viper.GetInt("ACCOUNT_DELETION_GRACE_DAYS")) * 24 * time.Hour
+	if DeletionGrace <= 0 {
+		DeletionGrace = 30 * 24 * time.Hour
+	}
+
+	// storage configuration
+	StorageDriver = viper.GetString("STORAGE_DRIVER")
+	if StorageDriver == "" {
+		StorageDriver = "local"
+	}
+	StorageLocalRoot = viper.GetString("STORAGE_LOCAL_ROOT")
+	if StorageLocalRoot == "" {
+		StorageLocalRoot = "./storage"
+	}
+	StorageS3Endpoint = viper.GetString("STORAGE_S3_ENDPOINT")
+	StorageS3AccessKey = viper.GetString("STORAGE_S3_ACCESS_KEY")
+	StorageS3SecretKey = viper.GetString("STORAGE_S3_SECRET_KEY")
+	StorageS3Bucket = viper.GetString("STORAGE_S3_BUCKET")
+	StorageS3Region = viper.GetString("STORAGE_S3_REGION")
+	StorageS3UseSSL = viper.GetBool("STORAGE_S3_USE_SSL")
+	// wajib diisi untuk driver local, tidak boleh sama dengan JWT_SECRET
+	StorageSigningKey = viper.GetString("STORAGE_SIGNING_KEY")
+	StorageURLTTL = time.Duration(viper.GetInt("STORAGE_URL_TTL_SECONDS")) * time.Second
+	if StorageURLTTL <= 0 {
+		StorageURLTTL = 15 * time.Minute
+	}
+	UploadMaxSize = viper.GetInt64("UPLOAD_MAX_SIZE_MB") << 20
+	if UploadMaxSize <= 0 {
+		UploadMaxSize = 10 << 20
+	}
+	for _, t := range strings.Split(viper.GetString("UPLOAD_ALLOWED_TYPES"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			UploadAllowedTypes = append(UploadAllowedTypes, t)
+		}
+	}
+	if len(UploadAllowedTypes) == 0 {
+		UploadAllowedTypes = []string{
+			"image/jpeg", "image/png", "image/webp", "image/gif",
+			"application/pdf", "text/plain", "text/csv",
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		}
+	}
+	// body request biasa, route upload punya limit sendiri (middleware.AllowUpload)
+	BodyLimit = viper.GetInt64("BODY_LIMIT_KB") << 10
+	if BodyLimit <= 0 {
+		BodyLimit = 1 << 20
+	}
 
 	// Redis / OIDC
 	RedisURL = viper.GetString("REDIS_URL")
 	if RedisURL == "" {
 		RedisURL = "redis://redis:6379/0"
 	}
+	CacheTTL = time.Duration(viper.GetInt("CACHE_TTL_SECONDS")) * time.Second
+	if CacheTTL <= 0 {
+		CacheTTL = 5 * time.Minute
+	}
+	RateLimitRequests = viper.GetInt("RATE_LIMIT_REQUESTS")
+	RateLimitWindow = time.Duration(viper.GetInt("RATE_LIMIT_WINDOW_SECONDS")) * time.Second
+	if RateLimitWindow <= 0 {
+		RateLimitWindow = time.Minute
+	}
+	RateLimitFailOpen = viper.GetBool("RATE_LIMIT_FAIL_OPEN")
+	for _, proxy := range strings.Split(viper.GetString("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			TrustedProxies = append(TrustedProxies, proxy)
+		}
+	}
+	// tanpa proxy terpercaya header IP bisa dipalsukan client
+	if len(TrustedProxies) > 0 {
+		ProxyHeader = viper.GetString("PROXY_HEADER")
+		if ProxyHeader == "" {
+			ProxyHeader = "X-Real-IP"
+		}
+	}
+
+	// usage quotas
+	Plans = defaultPlans
+	if raw := viper.GetString("PLANS"); raw != "" {
+		var plans map[string]Plan
+		if err := json.Unmarshal([]byte(raw), &plans); err != nil {
+			utils.Log.Warnf("Invalid PLANS, using the default plans: %v", err)
+		} else {
+			Plans = plans
+		}
+	}
+	DefaultPlan = viper.GetString("DEFAULT_PLAN")
+	if DefaultPlan == "" {
+		DefaultPlan = "free"
+	}
+	MeteringFlush = time.Duration(viper.GetInt("METERING_FLUSH_SECONDS")) * time.Second
+	if MeteringFlush <= 0 {
+		MeteringFlush = time.Minute
+	}
+	IdempotencyTTL = time.Duration(viper.GetInt("IDEMPOTENCY_TTL_HOURS")) * time.Hour
+	if IdempotencyTTL <= 0 {
+		IdempotencyTTL = 24 * time.Hour
+	}
 	Issuer = viper.GetString("ISSUER")
 	if Issuer == "" {
 		// fallback ke SSO_ISSUER jika kamu sudah pakai itu sebelumnya
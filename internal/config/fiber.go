@@ -14,7 +14,12 @@ func FiberConfig() fiber.Config {
 		ServerHeader:  "Fiber",
 		AppName:       "Fiber API",
 		ErrorHandler:  utils.ErrorHandler,
+		BodyLimit:     int(max(BodyLimit, UploadMaxSize+1<<20)), // largest route limit, see middleware.BodyLimit
 		JSONEncoder:   sonic.Marshal,
 		JSONDecoder:   sonic.Unmarshal,
+		// c.IP() dari ProxyHeader hanya untuk request lewat TrustedProxies
+		EnableTrustedProxyCheck: len(TrustedProxies) > 0,
+		TrustedProxies:          TrustedProxies,
+		ProxyHeader:             ProxyHeader,
 	}
 }
@@ -0,0 +1,13 @@
+package config
+
+// Plan maps a meter to its monthly quota. A meter missing from the plan is
+// unlimited, a quota of 0 means the plan does not include it.
+type Plan map[string]int64
+
+// defaultPlans are used when PLANS is not set, ex:
+//
+//	PLANS={"free":{"requests":10000,"imports":20},"pro":{"requests":1000000}}
+var defaultPlans = map[string]Plan{
+	"free": {"requests": 10000, "imports": 20},
+	"pro":  {"requests": 1000000},
+}
@@ -9,7 +9,6 @@ import (
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 func Connect(dbHost, dbName string) *gorm.DB {
@@ -19,7 +18,7 @@ func Connect(dbHost, dbName string) *gorm.DB {
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger:                 logger.Default.LogMode(logger.Info),
+		Logger:                 NewLogger(config.DBSlowQuery),
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
 		TranslateError:         true,
@@ -38,5 +37,13 @@ func Connect(dbHost, dbName string) *gorm.DB {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(60 * time.Minute)
 
+	if len(config.DBReplicaDSNs) > 0 {
+		if err := useReplicas(db, config.DBReplicaDSNs); err != nil {
+			utils.Log.Errorf("Failed to register read replicas: %+v", err)
+		} else {
+			utils.Log.Infof("Read replicas registered: %d", len(config.DBReplicaDSNs))
+		}
+	}
+
 	return db
 }
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	gormUtils "gorm.io/gorm/utils"
+)
+
+// Logger routes GORM through utils.Log, so SQL lines carry the request id
+// of the query context. Every query is logged at debug level, slower ones
+// than slowThreshold as warnings and failed ones as errors.
+type Logger struct {
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewLogger follows the level of utils.Log, LOG_LEVEL=debug logs every query.
+func NewLogger(slowThreshold time.Duration) *Logger {
+	level := logger.Warn
+	switch {
+	case utils.Log.IsLevelEnabled(logrus.DebugLevel):
+		level = logger.Info
+	case !utils.Log.IsLevelEnabled(logrus.WarnLevel):
+		level = logger.Error
+	}
+	return &Logger{level: level, slowThreshold: slowThreshold}
+}
+
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Info {
+		utils.Log.WithContext(ctx).Infof(msg, args...)
+	}
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Warn {
+		utils.Log.WithContext(ctx).Warnf(msg, args...)
+	}
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Error {
+		utils.Log.WithContext(ctx).Errorf(msg, args...)
+	}
+}
+
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	var level logrus.Level
+	var msg string
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = logrus.ErrorLevel, "Query failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		level, msg = logrus.WarnLevel, "Slow query over "+l.slowThreshold.String()
+	case l.level >= logger.Info:
+		level, msg = logrus.DebugLevel, "Query"
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	entry := utils.Log.WithContext(ctx).WithFields(logrus.Fields{
+		"sql":        sql,
+		"rows":       rows,
+		"elapsed_ms": float64(elapsed.Microseconds()) / 1000,
+		// harus dipanggil langsung dari Trace supaya frame gorm dilewati
+		"source": gormUtils.FileWithLineNum(),
+	})
+	if level == logrus.ErrorLevel {
+		entry = entry.WithError(err)
+	}
+	entry.Log(level, msg)
+}
+
+// ParamsFilter keeps query params (password hashes, tokens) out of the log,
+// they are only interpolated at LOG_LEVEL=trace.
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if utils.Log.IsLevelEnabled(logrus.TraceLevel) {
+		return sql, params
+	}
+	return sql, nil
+}
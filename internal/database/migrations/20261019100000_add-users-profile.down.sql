@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_users_email;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_email_lower;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_status;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS locale,
+    DROP COLUMN IF EXISTS avatar_url,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS password_hash,
+    DROP COLUMN IF EXISTS email;
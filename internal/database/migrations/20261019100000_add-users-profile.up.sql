@@ -0,0 +1,19 @@
+-- Users profile
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email          VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS password_hash  VARCHAR(255)   NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS status         VARCHAR(20)    NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS avatar_url     VARCHAR(2048),
+    ADD COLUMN IF NOT EXISTS locale         VARCHAR(35)    NOT NULL DEFAULT 'en',
+    ADD COLUMN IF NOT EXISTS timezone       VARCHAR(64)    NOT NULL DEFAULT 'UTC';
+
+-- existing users stay active, new users start as pending
+ALTER TABLE users ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE users ADD CONSTRAINT chk_users_status CHECK (status IN ('pending', 'active', 'suspended'));
+
+-- case-insensitive unique email, soft deleted users release their email.
+-- ON CONFLICT cannot infer an expression index from a column list, so emails
+-- are stored lower-case (normalized by the service layer) under a plain index
+-- instead of a lower(email) one. Users created before this stay NULL.
+ALTER TABLE users ADD CONSTRAINT chk_users_email_lower CHECK (email = lower(email));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- storage key of an uploaded avatar, avatar_url then points to /api/users/:id/avatar
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key VARCHAR(512);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS usage_records;
+ALTER TABLE users DROP COLUMN IF EXISTS plan;
@@ -0,0 +1,14 @@
+-- plan of the account, quotas per plan are configured with PLANS
+ALTER TABLE users ADD COLUMN IF NOT EXISTS plan VARCHAR(32) NOT NULL DEFAULT 'free';
+-- the default only fills existing rows, new users get DEFAULT_PLAN from the app
+ALTER TABLE users ALTER COLUMN plan DROP DEFAULT;
+
+-- monthly usage per meter, flushed from the Redis counters
+CREATE TABLE IF NOT EXISTS usage_records (
+    user_id         BIGINT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    meter           VARCHAR(64)    NOT NULL,
+    period          DATE           NOT NULL, -- first day of the month (UTC)
+    quantity        BIGINT         NOT NULL DEFAULT 0,
+    updated_at      TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, meter, period)
+);
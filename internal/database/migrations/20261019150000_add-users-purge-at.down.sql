@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
+DROP INDEX IF EXISTS idx_users_purge_at;
+ALTER TABLE users DROP COLUMN IF EXISTS purge_at;
@@ -0,0 +1,6 @@
+-- only self-deleted accounts get a purge_at, admin deletes are kept
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purge_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_users_purge_at ON users (purge_at) WHERE purge_at IS NOT NULL;
+
+-- access tokens issued before this are rejected (password change)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMPTZ;
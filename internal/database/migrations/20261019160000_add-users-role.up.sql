@@ -0,0 +1,2 @@
+-- key of config.RoleRights, checked by middleware.Auth
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+const replicaHealthInterval = 10 * time.Second
+
+type Replica struct {
+	Name    string
+	pool    *sql.DB
+	healthy atomic.Bool
+}
+
+// ReplicaSet is the dbresolver policy: it round-robins over healthy
+// replicas and falls back to the primary when none is available.
+type ReplicaSet struct {
+	primary  *sql.DB
+	replicas []*Replica
+	next     atomic.Uint64
+	stop     chan struct{}
+}
+
+var replicaSet *ReplicaSet
+
+func useReplicas(db *gorm.DB, dsns []string) error {
+	primary, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	set := &ReplicaSet{primary: primary, stop: make(chan struct{})}
+	// primary ikut didaftarkan supaya policy selalu dipanggil (dbresolver skip policy kalau cuma 1 replica)
+	dialectors := []gorm.Dialector{postgres.New(postgres.Config{Conn: primary})}
+
+	for i, dsn := range dsns {
+		pool, err := sql.Open("pgx", dsn)
+		if err != nil {
+			return fmt.Errorf("open replica %d: %w", i, err)
+		}
+		pool.SetMaxIdleConns(10)
+		pool.SetMaxOpenConns(100)
+		pool.SetConnMaxLifetime(60 * time.Minute)
+
+		replica := &Replica{Name: replicaName(i, dsn), pool: pool}
+		replica.healthy.Store(pool.Ping() == nil)
+		set.replicas = append(set.replicas, replica)
+		dialectors = append(dialectors, postgres.New(postgres.Config{Conn: pool}))
+	}
+
+	if err := db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   set,
+	})); err != nil {
+		return err
+	}
+
+	go set.monitor()
+	replicaSet = set
+	return nil
+}
+
+func (s *ReplicaSet) Resolve([]gorm.ConnPool) gorm.ConnPool {
+	healthy := make([]*sql.DB, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r.pool)
+		}
+	}
+	if len(healthy) == 0 {
+		return s.primary
+	}
+
+	return healthy[s.next.Add(1)%uint64(len(healthy))]
+}
+
+func (s *ReplicaSet) monitor() {
+	ticker := time.NewTicker(replicaHealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			for _, r := range s.replicas {
+				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				ok := r.pool.PingContext(ctx) == nil
+				cancel()
+
+				if r.healthy.Swap(ok) != ok {
+					if ok {
+						utils.Log.Infof("Replica %s is back up", r.Name)
+					} else {
+						utils.Log.Warnf("Replica %s is down, reads fall back", r.Name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// ReplicaStatus reports the last known health of every replica, keyed by name.
+func ReplicaStatus() map[string]bool {
+	status := map[string]bool{}
+	if replicaSet == nil {
+		return status
+	}
+	for _, r := range replicaSet.replicas {
+		status[r.Name] = r.healthy.Load()
+	}
+	return status
+}
+
+func CloseReplicas() {
+	if replicaSet == nil {
+		return
+	}
+	close(replicaSet.stop)
+	for _, r := range replicaSet.replicas {
+		if err := r.pool.Close(); err != nil {
+			utils.Log.Errorf("Error closing replica %s: %v", r.Name, err)
+		}
+	}
+	replicaSet = nil
+}
+
+func replicaName(i int, dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return fmt.Sprintf("replica-%d", i)
+}
+
+// ---- READ YOUR WRITES ----
+
+// SessionKey stores a *Session in the request locals (see middleware.ReadYourWrites).
+type SessionKey struct{}
+
+type Session struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+func session(ctx context.Context) *Session {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(SessionKey{}).(*Session)
+	return s
+}
+
+// MarkWrite pins the following reads of the same request to the primary
+// for config.DBStickyWindow.
+func MarkWrite(ctx context.Context) {
+	if s := session(ctx); s != nil {
+		s.mu.Lock()
+		s.lastWrite = time.Now()
+		s.mu.Unlock()
+	}
+}
+
+// Reader returns db bound to ctx, forced to the primary while the request
+// is inside its stickiness window.
+func Reader(ctx context.Context, db *gorm.DB) *gorm.DB {
+	q := db.WithContext(ctx)
+	if s := session(ctx); s != nil {
+		s.mu.Lock()
+		sticky := !s.lastWrite.IsZero() && time.Since(s.lastWrite) < config.DBStickyWindow
+		s.mu.Unlock()
+		if sticky {
+			return q.Clauses(dbresolver.Write)
+		}
+	}
+	return q
+}
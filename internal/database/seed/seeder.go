@@ -3,24 +3,31 @@ package seed
 import (
 	"fmt"
 
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
 	mUser "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/models"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/secure"
 
 	"gorm.io/gorm"
 )
 
 func Run(db *gorm.DB) error {
 	return db.Transaction(func(tx *gorm.DB) error {
-		// pw, err := secure.Hash("asdasdasd", nil)
-
-		// if err != nil {
-		// 	return err
-		// }
+		pw, err := secure.Hash("Asdasd123!", nil)
+		if err != nil {
+			return err
+		}
 
 		// ===== Users (user) =====
+		email := "admin@example.com"
 		user := mUser.User{
-			Name: "Super Admin",
+			Name:         "Super Admin",
+			Email:        &email,
+			PasswordHash: pw,
+			Status:       mUser.UserStatusActive,
+			Role:         "admin",
+			Plan:         config.DefaultPlan,
 		}
-		if err := tx.Where("email = ?", user.Id).FirstOrCreate(&user).Error; err != nil {
+		if err := tx.Where("email = ?", email).FirstOrCreate(&user).Error; err != nil {
 			return err
 		}
 
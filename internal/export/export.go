@@ -0,0 +1,327 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	XLSX   Format = "xlsx"
+	NDJSON Format = "ndjson"
+)
+
+var contentTypes = map[Format]string{
+	CSV:    "text/csv; charset=utf-8",
+	XLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	NDJSON: "application/x-ndjson",
+}
+
+// DefaultLang is used when the client asks for a language no column has.
+const DefaultLang = "en"
+
+// Column describes one exported field of T.
+type Column[T any] struct {
+	Key     string            // name used by ?columns= and as NDJSON key
+	Headers map[string]string // CSV / XLSX header per language, ex: {"en": "Name", "id": "Nama"}
+	Value   func(T) any
+}
+
+func (c Column[T]) header(lang string) string {
+	if h, ok := c.Headers[lang]; ok {
+		return h
+	}
+	if h, ok := c.Headers[DefaultLang]; ok {
+		return h
+	}
+	return c.Key
+}
+
+// Write streams every row as a download. The request picks:
+//
+//	?format=csv|xlsx|ndjson  (or the Accept header, default csv)
+//	?columns=id,email        (default all, in the given order)
+//	?lang=id                 (or Accept-Language, header language)
+//
+// rows must already carry the list filters, ex: repo.Stream(ctx, 0, filter).
+func Write[T any](c *fiber.Ctx, filename string, columns []Column[T], rows iter.Seq2[T, error]) error {
+	format, err := negotiate(c)
+	if err != nil {
+		return err
+	}
+	selected, err := selectColumns(columns, c.Query("columns"))
+	if err != nil {
+		return err
+	}
+	lang := language(c, columns)
+
+	name := fmt.Sprintf("%s-%s.%s", filename, time.Now().Format("20060102-150405"), format)
+	c.Attachment(name)
+
+	switch format {
+	case XLSX:
+		return response.Stream(c, contentTypes[format], func(w *bufio.Writer) error {
+			return writeXLSX(w, selected, lang, rows)
+		})
+	case NDJSON:
+		return response.NDJSON(c, ndjsonRows(selected, rows))
+	default:
+		return response.Stream(c, contentTypes[format], func(w *bufio.Writer) error {
+			return writeCSV(w, selected, lang, rows)
+		})
+	}
+}
+
+func negotiate(c *fiber.Ctx) (Format, error) {
+	if f := Format(strings.ToLower(c.Query("format"))); f != "" {
+		if _, ok := contentTypes[f]; !ok {
+			return "", fiber.NewError(fiber.StatusBadRequest, "Format must be one of csv, xlsx, ndjson")
+		}
+		return f, nil
+	}
+
+	accept := c.Get(fiber.HeaderAccept)
+	if accept == "" || accept == "*/*" {
+		return CSV, nil
+	}
+	switch c.Accepts(contentTypes[CSV], contentTypes[XLSX], contentTypes[NDJSON], "text/csv") {
+	case contentTypes[XLSX]:
+		return XLSX, nil
+	case contentTypes[NDJSON]:
+		return NDJSON, nil
+	case contentTypes[CSV], "text/csv":
+		return CSV, nil
+	}
+	return "", fiber.NewError(fiber.StatusNotAcceptable, "Export is available as csv, xlsx or ndjson")
+}
+
+func selectColumns[T any](columns []Column[T], query string) ([]Column[T], error) {
+	if strings.TrimSpace(query) == "" {
+		return columns, nil
+	}
+
+	selected := make([]Column[T], 0, len(columns))
+	for _, key := range strings.Split(query, ",") {
+		key = strings.TrimSpace(key)
+		i := slices.IndexFunc(columns, func(c Column[T]) bool { return c.Key == key })
+		if i < 0 {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Unknown column "+key)
+		}
+		selected = append(selected, columns[i])
+	}
+	return selected, nil
+}
+
+// language returns the first requested language some column has headers for.
+func language[T any](c *fiber.Ctx, columns []Column[T]) string {
+	offered := []string{}
+	for _, col := range columns {
+		for lang := range col.Headers {
+			if !slices.Contains(offered, lang) {
+				offered = append(offered, lang)
+			}
+		}
+	}
+	if len(offered) == 0 {
+		return DefaultLang
+	}
+
+	if lang := c.Query("lang"); slices.Contains(offered, lang) {
+		return lang
+	}
+	if lang := c.AcceptsLanguages(offered...); lang != "" {
+		return lang
+	}
+	return DefaultLang
+}
+
+// ---- WRITERS ----
+
+// flushEvery controls how many rows are buffered before they are pushed to the client.
+const flushEvery = 100
+
+func writeCSV[T any](w *bufio.Writer, columns []Column[T], lang string, rows iter.Seq2[T, error]) error {
+	// BOM supaya Excel membaca UTF-8 dengan benar
+	if _, err := w.WriteString("\ufeff"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = col.header(lang)
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	n := 0
+	for row, err := range rows {
+		if err != nil {
+			return err
+		}
+		for i, col := range columns {
+			record[i] = text(col.Value(row))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		if n++; n%flushEvery == 0 {
+			cw.Flush()
+			// gagal flush = client sudah disconnect, stop query
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ndjsonRows turns every row into an object of the selected columns, for
+// response.NDJSON.
+func ndjsonRows[T any](columns []Column[T], rows iter.Seq2[T, error]) iter.Seq2[json.RawMessage, error] {
+	keys := make([][]byte, len(columns))
+	for i, col := range columns {
+		keys[i], _ = json.Marshal(col.Key)
+	}
+
+	return func(yield func(json.RawMessage, error) bool) {
+		// object ditulis manual supaya urutan key mengikuti ?columns=
+		var buf bytes.Buffer
+		for row, err := range rows {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			buf.Reset()
+			buf.WriteByte('{')
+			for i, col := range columns {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				value, err := json.Marshal(col.Value(row))
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				buf.Write(keys[i])
+				buf.WriteByte(':')
+				buf.Write(value)
+			}
+			buf.WriteByte('}')
+
+			if !yield(buf.Bytes(), nil) {
+				return
+			}
+		}
+	}
+}
+
+// writeXLSX uses the excelize stream writer (rows spill to a temp file), the
+// zip itself can only be written once every row is known.
+func writeXLSX[T any](w *bufio.Writer, columns []Column[T], lang string, rows iter.Seq2[T, error]) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	header := make([]any, len(columns))
+	for i, col := range columns {
+		header[i] = excelize.Cell{StyleID: bold, Value: col.header(lang)}
+	}
+	if err := sw.SetRow("A1", header, excelize.RowOpts{Height: 18}); err != nil {
+		return err
+	}
+
+	line := 2
+	values := make([]any, len(columns))
+	for row, err := range rows {
+		if err != nil {
+			return err
+		}
+		for i, col := range columns {
+			values[i] = cell(col.Value(row))
+		}
+		axis, _ := excelize.CoordinatesToCellName(1, line)
+		if err := sw.SetRow(axis, values); err != nil {
+			return err
+		}
+		line++
+	}
+
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// cell dereferences pointers, excelize only knows plain values.
+func cell(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+
+	switch x := rv.Interface().(type) {
+	case time.Time:
+		if x.IsZero() {
+			return nil
+		}
+		return x.UTC()
+	case fmt.Stringer:
+		return x.String()
+	}
+	// named string types (ex: model.UserStatus)
+	if rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	return rv.Interface()
+}
+
+func text(v any) string {
+	switch x := cell(v).(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case string:
+		// cegah formula injection saat CSV dibuka di Excel / Sheets
+		if x != "" && strings.ContainsRune("=+-@\t\r", rune(x[0])) {
+			return "'" + x
+		}
+		return x
+	default:
+		return fmt.Sprint(x)
+	}
+}
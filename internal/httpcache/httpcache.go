@@ -0,0 +1,145 @@
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New sets Cache-Control on the GET / HEAD responses of a route and answers
+// If-None-Match / If-Modified-Since with 304:
+//
+//	route.Get("/:id", httpcache.New("private, no-cache"), ctrl.GetOne)
+//
+// Responses without an ETag get a strong one hashed from the body. Handlers
+// that know their validators before building the body (UpdatedAt, see Fresh)
+// skip that work instead.
+func New(cacheControl string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status != fiber.StatusOK && status != fiber.StatusNotModified {
+			return nil
+		}
+		if cacheControl != "" {
+			c.Set(fiber.HeaderCacheControl, cacheControl)
+		}
+		if status == fiber.StatusNotModified || c.Context().IsBodyStream() {
+			return nil
+		}
+
+		if len(c.Response().Header.Peek(fiber.HeaderETag)) == 0 {
+			c.Set(fiber.HeaderETag, Strong(c, c.Response().Body()))
+		}
+		if fresh(c) {
+			return NotModified(c)
+		}
+		return nil
+	}
+}
+
+// Strong is the ETag of one version of a resource, ex: Strong(c, user.Id, user.UpdatedAt).
+func Strong(c *fiber.Ctx, parts ...any) string {
+	return `"` + digest(c, parts) + `"`
+}
+
+// Weak is the ETag of a list, ex: Weak(c, maxUpdatedAt, count). The query
+// string (page, filters) is part of it.
+func Weak(c *fiber.Ctx, parts ...any) string {
+	return `W/"` + digest(c, append(parts, c.Request().URI().QueryString())) + `"`
+}
+
+// Fresh sets the validators of the response and reports whether the client
+// copy is still current, then the handler returns NotModified:
+//
+//	if httpcache.Fresh(c, httpcache.Strong(c, user.Id, user.UpdatedAt), user.UpdatedAt) {
+//		return httpcache.NotModified(c)
+//	}
+func Fresh(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	c.Set(fiber.HeaderETag, etag)
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	return fresh(c)
+}
+
+// NotModified sends a 304, the validators set before are kept.
+func NotModified(c *fiber.Ctx) error {
+	c.Status(fiber.StatusNotModified)
+	c.Response().ResetBody()
+	return nil
+}
+
+// fresh evaluates the conditional headers against the response validators,
+// If-None-Match wins over If-Modified-Since (RFC 9110 13.2.2).
+func fresh(c *fiber.Ctx) bool {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		etag := string(c.Response().Header.Peek(fiber.HeaderETag))
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(noneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// weak comparison
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if modifiedSince := c.Get(fiber.HeaderIfModifiedSince); modifiedSince != "" {
+		lastModified, err := http.ParseTime(string(c.Response().Header.Peek(fiber.HeaderLastModified)))
+		if err != nil {
+			return false
+		}
+		since, err := http.ParseTime(modifiedSince)
+		return err == nil && !lastModified.After(since)
+	}
+	return false
+}
+
+// digest hashes parts with the negotiated format, so JSON and MessagePack
+// representations of the same data get different ETags.
+func digest(c *fiber.Ctx, parts []any) string {
+	c.Vary(fiber.HeaderAccept)
+
+	h := sha256.New()
+	h.Write([]byte(c.Accepts(response.Formats...)))
+	for _, part := range parts {
+		h.Write([]byte{0})
+		write(h, part)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func write(h hash.Hash, part any) {
+	switch v := part.(type) {
+	case []byte:
+		h.Write(v)
+	case string:
+		h.Write([]byte(v))
+	case time.Time:
+		h.Write([]byte(v.UTC().Format(time.RFC3339Nano)))
+	default:
+		fmt.Fprint(h, v)
+	}
+}
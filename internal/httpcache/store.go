@@ -0,0 +1,261 @@
+package httpcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// CacheName identifies this cache in Cache-Status (RFC 9211).
+var CacheName = "api"
+
+const keyPrefix = "httpcache:"
+
+// client used by Cache / Invalidate, set once at startup with UseRedis
+var (
+	rdbMu sync.RWMutex
+	rdb   *redis.Client
+)
+
+func UseRedis(client *redis.Client) {
+	rdbMu.Lock()
+	defer rdbMu.Unlock()
+	rdb = client
+}
+
+func redisClient() *redis.Client {
+	rdbMu.RLock()
+	defer rdbMu.RUnlock()
+	return rdb
+}
+
+type CacheOptions struct {
+	TTL   time.Duration             // default 1 minute
+	Tags  []string                  // invalidated together by Invalidate, {param} is replaced by the route param, ex: "users:{id}"
+	Scope func(c *fiber.Ctx) string // part of the key, ex: PerUser, empty = shared by every client
+	Vary  []string                  // request headers part of the key, the negotiated format always is
+}
+
+// PerUser scopes entries to c.Locals("user"), which implements CacheScope
+// (ex: its id, or its role when every user of a role sees the same data).
+func PerUser(c *fiber.Ctx) string {
+	if user, ok := c.Locals("user").(interface{ CacheScope() string }); ok {
+		return user.CacheScope()
+	}
+	return ""
+}
+
+// entry is a stored response.
+type entry struct {
+	Status   int               `json:"status"`
+	Header   map[string]string `json:"header"`
+	Body     []byte            `json:"body"`
+	StoredAt time.Time         `json:"stored_at"`
+}
+
+// headers kept with the body, the rest is set again by the outer middleware
+var storedHeaders = []string{fiber.HeaderContentType, fiber.HeaderETag, fiber.HeaderLastModified, fiber.HeaderVary}
+
+// Cache stores 200 GET responses of a route in Redis, keyed by path, sorted
+// query string, scope and Vary headers:
+//
+//	route.Get("/", httpcache.New("no-cache"), httpcache.Cache(httpcache.CacheOptions{
+//		TTL:  config.CacheTTL,
+//		Tags: []string{"users:list"},
+//	}), ctrl.GetAll)
+//
+// Services drop entries after a write with Invalidate(ctx, "users:list"),
+// responses of its tags are then not stored for config.DBStickyWindow since
+// the handler may have read a replica that has not seen the write yet.
+// Cache-Status reports hit / miss, a request with Cache-Control: no-cache
+// skips the lookup and no-store skips the cache entirely.
+func Cache(opts CacheOptions) fiber.Handler {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+
+	return func(c *fiber.Ctx) error {
+		client := redisClient()
+		if client == nil || (c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead) {
+			return c.Next()
+		}
+
+		directives := strings.ToLower(c.Get(fiber.HeaderCacheControl))
+		if strings.Contains(directives, "no-store") {
+			c.Set("Cache-Status", CacheName+"; fwd=request")
+			return c.Next()
+		}
+
+		key := cacheKey(c, opts)
+		fwd := "request"
+		if !strings.Contains(directives, "no-cache") {
+			fwd = "miss"
+			if e, ok := load(c.Context(), client, key); ok {
+				for name, value := range e.Header {
+					c.Set(name, value)
+				}
+				age := time.Since(e.StoredAt)
+				c.Set(fiber.HeaderAge, strconv.Itoa(int(age.Seconds())))
+				c.Set("Cache-Status", fmt.Sprintf("%s; hit; ttl=%d", CacheName, int((opts.TTL-age).Seconds())))
+				return c.Status(e.Status).Send(e.Body)
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		// HEAD tidak punya body, jangan disimpan
+		if c.Method() != fiber.MethodGet || c.Response().StatusCode() != fiber.StatusOK || c.Context().IsBodyStream() {
+			c.Set("Cache-Status", CacheName+"; fwd="+fwd)
+			return nil
+		}
+
+		e := entry{
+			Status:   fiber.StatusOK,
+			Header:   map[string]string{},
+			Body:     c.Response().Body(),
+			StoredAt: time.Now(),
+		}
+		for _, name := range storedHeaders {
+			if value := c.Response().Header.Peek(name); len(value) > 0 {
+				e.Header[name] = string(value)
+			}
+		}
+		tags := resolveTags(c, opts.Tags)
+		if recentlyInvalidated(c.Context(), client, tags) {
+			c.Set("Cache-Status", CacheName+"; fwd="+fwd)
+			return nil
+		}
+		store(c.Context(), client, key, tags, e, opts.TTL)
+		c.Set("Cache-Status", CacheName+"; fwd="+fwd+"; stored")
+		return nil
+	}
+}
+
+// Invalidate drops every entry stored under one of tags.
+func Invalidate(ctx context.Context, tags ...string) {
+	client := redisClient()
+	if client == nil || len(tags) == 0 {
+		return
+	}
+	// tetap jalan walau request sudah selesai
+	ctx = context.WithoutCancel(ctx)
+
+	for _, tag := range tags {
+		tagKey := keyPrefix + "tag:" + tag
+		keys, err := client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			utils.Log.WithContext(ctx).Warnf("Response cache invalidate %s failed: %v", tag, err)
+			continue
+		}
+		_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, append(keys, tagKey)...)
+			// penanda untuk recentlyInvalidated
+			pipe.Set(ctx, keyPrefix+"invalidated:"+tag, 1, config.DBStickyWindow)
+			return nil
+		})
+		if err != nil {
+			utils.Log.WithContext(ctx).Warnf("Response cache invalidate %s failed: %v", tag, err)
+		}
+	}
+}
+
+// recentlyInvalidated reports whether one of tags was invalidated within
+// config.DBStickyWindow, or the check failed.
+func recentlyInvalidated(ctx context.Context, client *redis.Client, tags []string) bool {
+	if len(tags) == 0 {
+		return false
+	}
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = keyPrefix + "invalidated:" + tag
+	}
+	n, err := client.Exists(ctx, keys...).Result()
+	if err != nil {
+		utils.Log.WithContext(ctx).Warnf("Response cache invalidation check failed: %v", err)
+		return true
+	}
+	return n > 0
+}
+
+func cacheKey(c *fiber.Ctx, opts CacheOptions) string {
+	var query []string
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		query = append(query, string(key)+"="+string(value))
+	})
+	slices.Sort(query)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", c.Path(), strings.Join(query, "&"), c.Accepts(response.Formats...))
+	if opts.Scope != nil {
+		fmt.Fprintf(h, "\x00%s", opts.Scope(c))
+	}
+	for _, name := range opts.Vary {
+		fmt.Fprintf(h, "\x00%s", c.Get(name))
+	}
+	return keyPrefix + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func resolveTags(c *fiber.Ctx, templates []string) []string {
+	resolved := make([]string, len(templates))
+	for i, tag := range templates {
+		for _, param := range c.Route().Params {
+			tag = strings.ReplaceAll(tag, "{"+param+"}", c.Params(param))
+		}
+		resolved[i] = tag
+	}
+	return resolved
+}
+
+func load(ctx context.Context, client *redis.Client, key string) (entry, bool) {
+	var e entry
+	data, err := client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			utils.Log.WithContext(ctx).Warnf("Response cache get %s failed: %v", key, err)
+		}
+		return e, false
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		utils.Log.WithContext(ctx).Warnf("Response cache decode %s failed: %v", key, err)
+		return e, false
+	}
+	return e, true
+}
+
+func store(ctx context.Context, client *redis.Client, key string, tags []string, e entry, ttl time.Duration) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		utils.Log.WithContext(ctx).Warnf("Response cache encode %s failed: %v", key, err)
+		return
+	}
+
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, ttl)
+		for _, tag := range tags {
+			tagKey := keyPrefix + "tag:" + tag
+			pipe.SAdd(ctx, tagKey, key)
+			// set tag hidup selama entry terakhirnya
+			pipe.Expire(ctx, tagKey, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.Log.WithContext(ctx).Warnf("Response cache set %s failed: %v", key, err)
+	}
+}
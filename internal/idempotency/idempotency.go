@@ -0,0 +1,312 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/ratelimit"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/requestid"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Header is draft-ietf-httpapi-idempotency-key-header.
+const Header = "Idempotency-Key"
+
+// HeaderReplayed is set on responses replayed from the store.
+const HeaderReplayed = "Idempotent-Replayed"
+
+const (
+	keyPrefix = "idempotency:"
+	// lock of a request in flight, extended while the handler runs so only a
+	// crashed process frees the key after it
+	lockTTL = time.Minute
+	// lock attempts when the key is released between lock and replay
+	lockAttempts = 3
+	// keys longer than this are rejected
+	maxKeyLength = 255
+)
+
+// idempotency error catalogue
+var (
+	ErrInvalidKey = apperror.Define(apperror.BadRequest, "idempotency_key_invalid", "Idempotency-Key must be 1-255 characters")
+	ErrInFlight   = apperror.Define(apperror.Conflict, "idempotency_key_in_flight", "A request with this Idempotency-Key is still being processed")
+	ErrKeyReused  = apperror.Define(apperror.Unprocessable, "idempotency_key_reused", "Idempotency-Key was already used with a different request")
+)
+
+type Options struct {
+	TTL   time.Duration             // how long responses are replayed, default 24 hours
+	Scope func(c *fiber.Ctx) string // whose keys, default ByUserOrBody
+}
+
+// errReleased is returned by replay when the key was freed after lock failed.
+var errReleased = errors.New("idempotency key released")
+
+// ByUserOrBody scopes keys to the authenticated user. Anonymous requests are
+// scoped by their body, a shared IP (proxy, NAT) must not let one client
+// replay the response of another with a reused or guessed key.
+func ByUserOrBody(c *fiber.Ctx) string {
+	if _, ok := c.Locals("user").(interface{ RateLimitKey() string }); ok {
+		return ratelimit.ByUser(c)
+	}
+	sum := sha256.Sum256(c.Body())
+	return "body:" + hex.EncodeToString(sum[:])
+}
+
+// client used by New, set once at startup with UseRedis
+var (
+	rdbMu sync.RWMutex
+	rdb   *redis.Client
+)
+
+func UseRedis(client *redis.Client) {
+	rdbMu.Lock()
+	defer rdbMu.Unlock()
+	rdb = client
+}
+
+func redisClient() *redis.Client {
+	rdbMu.RLock()
+	defer rdbMu.RUnlock()
+	return rdb
+}
+
+// entry is the state of a key, Status is 0 while the request is in flight.
+type entry struct {
+	Fingerprint string            `json:"fingerprint"`
+	Token       string            `json:"token,omitempty"` // owner of the lock, see extend
+	Status      int               `json:"status,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// headers replayed with the body
+var storedHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation, fiber.HeaderETag, fiber.HeaderLastModified}
+
+// New makes POST / PATCH requests carrying an Idempotency-Key safe to retry:
+//
+//	route.Post("/", idempotency.New(idempotency.Options{TTL: config.IdempotencyTTL}), ctrl.CreateOne)
+//
+// The first request runs and its response is stored, repeats with the same
+// key and body get that response again (Idempotent-Replayed: true) with their
+// own request_id. A repeat while the first one still runs gets 409, the same
+// key with another method, path or body gets 422 (anonymous requests are
+// scoped by body, see ByUserOrBody, another body is another key). Returned
+// errors and 5xx responses are not stored so the client can retry. Requests
+// without the header, and every request when Redis is unavailable, run as
+// usual.
+func New(opts Options) fiber.Handler {
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.Scope == nil {
+		opts.Scope = ByUserOrBody
+	}
+
+	return func(c *fiber.Ctx) error {
+		client := redisClient()
+		idemKey := c.Get(Header)
+		if client == nil || idemKey == "" || (c.Method() != fiber.MethodPost && c.Method() != fiber.MethodPatch) {
+			return c.Next()
+		}
+		if len(idemKey) > maxKeyLength {
+			return ErrInvalidKey
+		}
+
+		key := storeKey(opts.Scope(c), idemKey)
+		fingerprint := fingerprint(c)
+
+		token := utils.NewUUID().String()
+		for attempt := 1; ; attempt++ {
+			locked, err := lock(c.Context(), client, key, fingerprint, token)
+			if err != nil {
+				utils.Log.WithContext(c.Context()).Warnf("Idempotency lock %s failed: %v", key, err)
+				return c.Next()
+			}
+			if locked {
+				break
+			}
+			err = replay(c, client, key, fingerprint)
+			if !errors.Is(err, errReleased) {
+				return err
+			}
+			if attempt == lockAttempts {
+				c.Set(fiber.HeaderRetryAfter, "1")
+				return ErrInFlight
+			}
+		}
+
+		stop := extend(c.Context(), client, key, token)
+		err := c.Next()
+		stop()
+		if err != nil {
+			// response error baru dirender error handler, tidak disimpan
+			release(c.Context(), client, key, token)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError || c.Context().IsBodyStream() {
+			release(c.Context(), client, key, token)
+			return nil
+		}
+
+		e := entry{
+			Fingerprint: fingerprint,
+			Status:      status,
+			Header:      map[string]string{},
+			Body:        c.Response().Body(),
+		}
+		for _, name := range storedHeaders {
+			if value := c.Response().Header.Peek(name); len(value) > 0 {
+				e.Header[name] = string(value)
+			}
+		}
+		data, err := json.Marshal(e)
+		if err == nil {
+			err = client.Set(context.WithoutCancel(c.Context()), key, data, opts.TTL).Err()
+		}
+		if err != nil {
+			utils.Log.WithContext(c.Context()).Warnf("Idempotency store %s failed: %v", key, err)
+			release(c.Context(), client, key, token)
+		}
+		return nil
+	}
+}
+
+// lock claims key for this request, false when the key is already used.
+func lock(ctx context.Context, client *redis.Client, key, fingerprint, token string) (bool, error) {
+	data, err := json.Marshal(entry{Fingerprint: fingerprint, Token: token})
+	if err != nil {
+		return false, err
+	}
+	return client.SetNX(ctx, key, data, lockTTL).Result()
+}
+
+// KEYS: key. ARGV: token. Returns 0 unless key is still the lock owned by
+// token, not the stored response nor a lock taken after it expired.
+const ownedLua = `
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return 0
+end
+local ok, e = pcall(cjson.decode, data)
+if not ok or e.token ~= ARGV[1] then
+	return 0
+end
+`
+
+// ARGV: token, ttl ms. Extends the lock while it is owned by token.
+var extendScript = redis.NewScript(ownedLua + `return redis.call('PEXPIRE', KEYS[1], ARGV[2])`)
+
+// ARGV: token. Deletes the lock while it is owned by token.
+var releaseScript = redis.NewScript(ownedLua + `return redis.call('DEL', KEYS[1])`)
+
+// extend keeps the lock of key alive until stop is called, a handler slower
+// than lockTTL must not let a retry run it a second time.
+func extend(ctx context.Context, client *redis.Client, key, token string) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := extendScript.Run(context.WithoutCancel(ctx), client, []string{key}, token, lockTTL.Milliseconds()).Err()
+				if err != nil {
+					utils.Log.WithContext(ctx).Warnf("Idempotency extend %s failed: %v", key, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// release frees key so the request can be retried, only while this request
+// (token) still holds the lock.
+func release(ctx context.Context, client *redis.Client, key, token string) {
+	if err := releaseScript.Run(context.WithoutCancel(ctx), client, []string{key}, token).Err(); err != nil {
+		utils.Log.WithContext(ctx).Warnf("Idempotency release %s failed: %v", key, err)
+	}
+}
+
+func replay(c *fiber.Ctx, client *redis.Client, key, fingerprint string) error {
+	data, err := client.Get(c.Context(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// lock baru saja dilepas (error / 5xx), coba lock lagi
+		return errReleased
+	}
+	if err != nil {
+		utils.Log.WithContext(c.Context()).Warnf("Idempotency get %s failed: %v", key, err)
+		return ErrInFlight
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		utils.Log.WithContext(c.Context()).Warnf("Idempotency decode %s failed: %v", key, err)
+		return ErrInFlight
+	}
+	if e.Fingerprint != fingerprint {
+		return ErrKeyReused
+	}
+	if e.Status == 0 {
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return ErrInFlight
+	}
+
+	for name, value := range e.Header {
+		c.Set(name, value)
+	}
+	c.Set(HeaderReplayed, "true")
+	return c.Status(e.Status).Send(withRequestID(c, e))
+}
+
+// withRequestID returns the stored body with the request_id of this request,
+// logs and support tickets point to the replay, not the first request.
+func withRequestID(c *fiber.Ctx, e entry) []byte {
+	mediaType := response.MediaType(e.Header[fiber.HeaderContentType])
+	if mediaType != fiber.MIMEApplicationJSON && mediaType != response.ProblemContentType {
+		return e.Body
+	}
+
+	var body struct {
+		RequestID json.RawMessage `json:"request_id"`
+	}
+	if err := json.Unmarshal(e.Body, &body); err != nil || body.RequestID == nil {
+		return e.Body
+	}
+	id, err := json.Marshal(requestid.From(c.Context()))
+	if err != nil {
+		return e.Body
+	}
+	// ganti di tempat supaya urutan field tetap
+	return bytes.Replace(e.Body, append([]byte(`"request_id":`), body.RequestID...), append([]byte(`"request_id":`), id...), 1)
+}
+
+// storeKey hashes the client key with its scope, one client cannot replay
+// the responses of another.
+func storeKey(scope, idemKey string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + idemKey))
+	return keyPrefix + hex.EncodeToString(sum[:16])
+}
+
+// fingerprint identifies the request a key was first used with.
+func fingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", c.Method(), c.Path())
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
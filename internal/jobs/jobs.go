@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/requestid"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+const (
+	keyPrefix = "jobs:"
+	// job record stays readable this long after it finished
+	retention = 24 * time.Hour
+	// background jobs (Start) running at the same time per instance
+	workers = 4
+)
+
+var ErrNotFound = errors.New("job not found")
+
+// Job is the status record kept in Redis, polled by clients.
+type Job struct {
+	ID         string          `json:"id"`
+	Kind       string          `json:"kind"`
+	OwnerID    uint            `json:"owner_id"`
+	Status     Status          `json:"status"`
+	Done       int             `json:"done"`
+	Total      int             `json:"total"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Progress reports how many of total units are processed.
+type Progress func(done, total int)
+
+// Func does the work, its result is stored as JSON on the job.
+type Func func(ctx context.Context, progress Progress) (any, error)
+
+var (
+	slots = make(chan struct{}, workers)
+
+	// lifecycle of the process, see Bind
+	lifecycle = context.Background()
+	running   sync.WaitGroup
+)
+
+// Bind ties background jobs to ctx, the lifecycle of this process: when it is
+// cancelled the running jobs are cancelled too and recorded as failed. Call it
+// before serving, Wait after cancelling ctx.
+func Bind(ctx context.Context) {
+	lifecycle = ctx
+}
+
+// Wait blocks until every job started by Start returned.
+func Wait() {
+	running.Wait()
+}
+
+// Start records a queued job and runs fn in the background of this process.
+// The job outlives the request ctx but keeps its request id, it is cancelled
+// on shutdown (see Bind).
+func Start(ctx context.Context, rdb *redis.Client, kind string, ownerID uint, fn Func) (*Job, error) {
+	job, err := create(ctx, rdb, kind, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobCtx := requestid.With(lifecycle, requestid.From(ctx))
+	running.Add(1)
+	go func() {
+		defer running.Done()
+		run(jobCtx, rdb, *job, fn)
+	}()
+	return job, nil
+}
+
+// Run is Start for small workloads: fn runs inline with ctx and the finished
+// job is returned, so the caller answers with the same record it would poll.
+// It does not wait for the workers of Start, busy background jobs must not
+// hold a request.
+func Run(ctx context.Context, rdb *redis.Client, kind string, ownerID uint, fn Func) (*Job, error) {
+	job, err := create(ctx, rdb, kind, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	done := execute(ctx, rdb, *job, fn)
+	return &done, nil
+}
+
+func create(ctx context.Context, rdb *redis.Client, kind string, ownerID uint) (*Job, error) {
+	job := &Job{
+		ID:        utils.NewULID().String(),
+		Kind:      kind,
+		OwnerID:   ownerID,
+		Status:    StatusQueued,
+		CreatedAt: time.Now(),
+	}
+	if err := save(ctx, rdb, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// run waits for one of the workers, then executes the background job.
+func run(ctx context.Context, rdb *redis.Client, job Job, fn Func) Job {
+	select {
+	case slots <- struct{}{}:
+		defer func() { <-slots }()
+	case <-ctx.Done():
+		return finish(ctx, rdb, job, nil, ctx.Err())
+	}
+	return execute(ctx, rdb, job, fn)
+}
+
+func execute(ctx context.Context, rdb *redis.Client, job Job, fn Func) Job {
+	job.Status = StatusRunning
+	_ = save(ctx, rdb, &job)
+
+	last := time.Now()
+	progress := func(done, total int) {
+		job.Done, job.Total = done, total
+		// jangan spam redis, cukup tiap detik
+		if time.Since(last) >= time.Second {
+			last = time.Now()
+			_ = save(ctx, rdb, &job)
+		}
+	}
+
+	result, err := func() (result any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.WithContext(ctx).Errorf("Job %s (%s) panicked: %v", job.ID, job.Kind, r)
+				err = errors.New("internal error")
+			}
+		}()
+		return fn(ctx, progress)
+	}()
+	return finish(ctx, rdb, job, result, err)
+}
+
+// finish records the outcome, also when ctx was cancelled by shutdown.
+func finish(ctx context.Context, rdb *redis.Client, job Job, result any, err error) Job {
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		utils.Log.WithContext(ctx).Errorf("Job %s (%s) failed: %+v", job.ID, job.Kind, err)
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusDone
+		if job.Result, err = json.Marshal(result); err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+		}
+	}
+
+	if err := save(context.WithoutCancel(ctx), rdb, &job); err != nil {
+		utils.Log.WithContext(ctx).Errorf("Failed to save job %s: %+v", job.ID, err)
+	}
+	return job
+}
+
+// Get loads a job, only its owner can see it.
+func Get(ctx context.Context, rdb *redis.Client, id string, ownerID uint) (*Job, error) {
+	raw, err := rdb.Get(ctx, keyPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return nil, err
+	}
+	if job.OwnerID != ownerID {
+		return nil, ErrNotFound
+	}
+	return &job, nil
+}
+
+func save(ctx context.Context, rdb *redis.Client, job *Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(ctx, keyPrefix+job.ID, raw, retention).Err()
+}
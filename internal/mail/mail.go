@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+)
+
+// Send delivers a plain text email through the configured SMTP server.
+// Without SMTP_HOST (local development) the message is only logged.
+func Send(to, subject, body string) error {
+	if config.SMTPHost == "" {
+		utils.Log.Infof("Email to %s (SMTP disabled)\nSubject: %s\n\n%s", to, subject, body)
+		return nil
+	}
+
+	msg := strings.Join([]string{
+		"From: " + config.EmailFrom,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	addr := fmt.Sprintf("%s:%d", config.SMTPHost, config.SMTPPort)
+	auth := smtp.PlainAuth("", config.SMTPUsername, config.SMTPPassword, config.SMTPHost)
+	return smtp.SendMail(addr, auth, config.EmailFrom, []string{to}, []byte(msg))
+}
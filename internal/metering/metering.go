@@ -0,0 +1,166 @@
+package metering
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// MeterRequests counts the requests of the routes metered with it:
+//
+//	route.Get("/", middleware.Auth(s), metering.Meter(metering.MeterRequests, 1), ctrl.GetAll)
+const MeterRequests = "requests"
+
+// metering error catalogue
+var (
+	ErrQuotaExceeded = apperror.Define(apperror.RateLimited, "quota_exceeded", "Monthly quota exceeded")
+	ErrNotInPlan     = apperror.Define(apperror.PaymentRequired, "quota_not_in_plan", "Your plan does not include this feature")
+)
+
+// Account is metered, implemented by the user in c.Locals("user").
+type Account interface {
+	AccountID() uint
+	PlanName() string
+}
+
+// clients set once at startup with UseRedis / UseDB
+var (
+	mu  sync.RWMutex
+	rdb *redis.Client
+	db  *gorm.DB
+)
+
+func UseRedis(client *redis.Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	rdb = client
+}
+
+func UseDB(conn *gorm.DB) {
+	mu.Lock()
+	defer mu.Unlock()
+	db = conn
+}
+
+func clients() (*redis.Client, *gorm.DB) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return rdb, db
+}
+
+// Meter charges units of meter to the authenticated user of a successful
+// request, after middleware.Auth:
+//
+//	route.Post("/import", middleware.Auth(s), metering.Meter("imports", 1), importCtrl.Import)
+//
+// The units are reserved before the handler runs, so concurrent requests
+// cannot go over the quota, and given back when it fails (error or status
+// >= 400). Requests without an authenticated user are not metered.
+func Meter(meter string, units int64) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		account, ok := c.Locals("user").(Account)
+		if !ok {
+			return c.Next()
+		}
+
+		counted, err := consume(c.Context(), account, meter, units)
+		if errors.Is(err, ErrQuotaExceeded) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(time.Until(nextPeriod(time.Now())).Seconds())+1))
+		}
+		if err != nil {
+			return err
+		}
+
+		err = c.Next()
+		if counted && (err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest) {
+			// request gagal, tidak ditagih
+			Record(context.WithoutCancel(c.Context()), account.AccountID(), meter, -units)
+		}
+		return err
+	}
+}
+
+// Consume counts units of meter when they fit in the monthly quota of the
+// account's plan. When Redis is unavailable usage is not counted.
+func Consume(ctx context.Context, account Account, meter string, units int64) error {
+	_, err := consume(ctx, account, meter, units)
+	return err
+}
+
+// consume is Consume, counted is false when the units were not counted.
+func consume(ctx context.Context, account Account, meter string, units int64) (counted bool, err error) {
+	client, conn := clients()
+	if client == nil {
+		return false, nil
+	}
+
+	quota, limited := Quota(account.PlanName(), meter)
+	if limited && quota == 0 {
+		return false, ErrNotInPlan.With("meter", meter)
+	}
+	if !limited {
+		quota = -1
+	}
+
+	values, err := count(ctx, client, conn, account.AccountID(), meter, units, quota)
+	if err != nil {
+		utils.Log.WithContext(ctx).Warnf("Metering %s of user %d failed: %v", meter, account.AccountID(), err)
+		return false, nil
+	}
+	if values[0] == 0 {
+		return false, ErrQuotaExceeded.
+			With("meter", meter).
+			With("limit", quota).
+			With("used", values[1]).
+			With("resets_at", nextPeriod(time.Now()))
+	}
+	return true, nil
+}
+
+// Record counts units of meter without checking the quota, for usage known
+// after the work is done (ex: rows imported). Negative units give usage back.
+func Record(ctx context.Context, accountID uint, meter string, units int64) {
+	client, conn := clients()
+	if client == nil {
+		return
+	}
+
+	if _, err := count(ctx, client, conn, accountID, meter, units, -1); err != nil {
+		utils.Log.WithContext(ctx).Warnf("Metering %s of user %d failed: %v", meter, accountID, err)
+	}
+}
+
+// Quota returns the monthly quota of meter in plan, limited is false when
+// the meter is unlimited.
+func Quota(plan, meter string) (quota int64, limited bool) {
+	quota, limited = config.Plans[planName(plan)][meter]
+	return quota, limited
+}
+
+// planName resolves unknown plans to config.DefaultPlan.
+func planName(plan string) string {
+	if _, ok := config.Plans[plan]; ok {
+		return plan
+	}
+	return config.DefaultPlan
+}
+
+// period is the month usage is counted in, in UTC.
+func period(t time.Time) time.Time {
+	y, m, _ := t.UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextPeriod(t time.Time) time.Time {
+	return period(t).AddDate(0, 1, 0)
+}
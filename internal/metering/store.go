@@ -0,0 +1,222 @@
+package metering
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	keyPrefix = "metering:"
+	// counter keys changed since the last flush
+	dirtyKey = keyPrefix + "dirty"
+	// counters outlive their month so the last increments still get flushed
+	keyGrace = 7 * 24 * time.Hour
+	// keys flushed per batch
+	flushBatch = 100
+)
+
+// UsageRecord is the monthly total of a meter, the Redis counter is the
+// source of truth until the month ends.
+type UsageRecord struct {
+	UserId    uint      `gorm:"primaryKey"`
+	Meter     string    `gorm:"primaryKey"`
+	Period    time.Time `gorm:"primaryKey;type:date"`
+	Quantity  int64
+	UpdatedAt time.Time
+}
+
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
+
+// KEYS: counter hash, dirty set. ARGV: meter, units, quota (-1 unlimited),
+// ttl seconds, check (1 returns {-1, 0} when the counter must be seeded first).
+// Returns {allowed, used}.
+var consumeScript = redis.NewScript(`
+if ARGV[5] == '1' and redis.call('EXISTS', KEYS[1]) == 0 then
+	return {-1, 0}
+end
+
+local units, quota = tonumber(ARGV[2]), tonumber(ARGV[3])
+local used = redis.call('HINCRBY', KEYS[1], ARGV[1], units)
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+if quota >= 0 and units > 0 and used > quota then
+	redis.call('HINCRBY', KEYS[1], ARGV[1], -units)
+	return {0, used - units}
+end
+
+redis.call('SADD', KEYS[2], KEYS[1])
+return {1, used}
+`)
+
+// count runs consumeScript, the counter is seeded from Postgres the first
+// time it is used in a month (or after Redis lost it). Nothing is counted
+// when the seed fails, flush would overwrite Postgres with a total from zero.
+func count(ctx context.Context, client *redis.Client, conn *gorm.DB, accountID uint, meter string, units, quota int64) ([]int64, error) {
+	now := time.Now()
+	key := counterKey(accountID, now)
+	args := []any{meter, units, quota, int(time.Until(nextPeriod(now).Add(keyGrace)).Seconds())}
+
+	values, err := consumeScript.Run(ctx, client, []string{key, dirtyKey}, append(args, 1)...).Int64Slice()
+	if err != nil || values[0] != -1 {
+		return values, err
+	}
+	if err := seed(ctx, client, conn, key, accountID, now); err != nil {
+		return nil, fmt.Errorf("seed %s: %w", key, err)
+	}
+	return consumeScript.Run(ctx, client, []string{key, dirtyKey}, append(args, 0)...).Int64Slice()
+}
+
+func seed(ctx context.Context, client *redis.Client, conn *gorm.DB, key string, accountID uint, now time.Time) error {
+	if conn == nil {
+		return nil
+	}
+
+	var records []UsageRecord
+	if err := conn.WithContext(ctx).
+		Where("user_id = ? AND period = ?", accountID, period(now)).
+		Find(&records).Error; err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, r := range records {
+			// counter yang sudah ada lebih baru dari Postgres
+			pipe.HSetNX(ctx, key, r.Meter, r.Quantity)
+		}
+		pipe.ExpireAt(ctx, key, nextPeriod(now).Add(keyGrace))
+		return nil
+	})
+	return err
+}
+
+func counterKey(accountID uint, t time.Time) string {
+	return fmt.Sprintf("%s%s:%d", keyPrefix, period(t).Format("2006-01"), accountID)
+}
+
+func parseKey(key string) (accountID uint, p time.Time, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(key, keyPrefix), ":")
+	if len(parts) != 2 {
+		return 0, p, false
+	}
+	p, err := time.Parse("2006-01", parts[0])
+	if err != nil {
+		return 0, p, false
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, p, false
+	}
+	return uint(id), p, true
+}
+
+// Flush writes the counters changed since the last flush to usage_records.
+// Totals are written, not increments, so a failed flush is simply retried.
+func Flush(ctx context.Context) error {
+	client, conn := clients()
+	if client == nil || conn == nil {
+		return nil
+	}
+
+	for {
+		keys, err := client.SPopN(ctx, dirtyKey, flushBatch).Result()
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+
+		if err := flush(ctx, client, conn, keys); err != nil {
+			// kembalikan supaya ikut di flush berikutnya
+			members := make([]any, len(keys))
+			for i, key := range keys {
+				members[i] = key
+			}
+			client.SAdd(context.WithoutCancel(ctx), dirtyKey, members...)
+			return err
+		}
+	}
+}
+
+func flush(ctx context.Context, client *redis.Client, conn *gorm.DB, keys []string) error {
+	var records []UsageRecord
+	for _, key := range keys {
+		accountID, p, ok := parseKey(key)
+		if !ok {
+			continue
+		}
+		values, err := client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		for meter, value := range values {
+			quantity, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			records = append(records, UsageRecord{UserId: accountID, Meter: meter, Period: p, Quantity: quantity})
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	// user yang sudah di-purge akan melanggar foreign key
+	ids := make([]uint, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, r.UserId)
+	}
+	var existing []uint
+	if err := conn.WithContext(ctx).Table("users").Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		return err
+	}
+	kept := records[:0]
+	for _, r := range records {
+		for _, id := range existing {
+			if r.UserId == id {
+				kept = append(kept, r)
+				break
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	return conn.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "meter"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			// total Redis sudah di-seed dari Postgres, refund boleh menurunkan
+			"quantity":   gorm.Expr("excluded.quantity"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&kept).Error
+}
+
+// FlushLoop flushes every interval until ctx is done. Counters stay in
+// Redis, what a stopped process did not flush is flushed by the next one.
+func FlushLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := Flush(ctx); err != nil {
+				utils.Log.Errorf("Failed to flush usage: %+v", err)
+			}
+		}
+	}
+}
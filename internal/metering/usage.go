@@ -0,0 +1,94 @@
+package metering
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+)
+
+// Report is the usage of an account in the current period.
+type Report struct {
+	Plan        string       `json:"plan"`
+	PeriodStart time.Time    `json:"period_start"`
+	ResetsAt    time.Time    `json:"resets_at"`
+	Meters      []MeterUsage `json:"meters"`
+}
+
+type MeterUsage struct {
+	Meter     string `json:"meter"`
+	Used      int64  `json:"used"`
+	Limit     *int64 `json:"limit"` // null when unlimited
+	Remaining *int64 `json:"remaining"`
+}
+
+// Usage reports every meter of the account's plan plus the unlimited ones
+// it used. Counters are read from Redis, or from the last flush without it.
+func Usage(ctx context.Context, account Account) (*Report, error) {
+	client, conn := clients()
+	now := time.Now()
+	used := map[string]int64{}
+
+	fromRedis := false
+	if client != nil {
+		key := counterKey(account.AccountID(), now)
+		values, err := client.HGetAll(ctx, key).Result()
+		if err == nil && len(values) == 0 {
+			if err = seed(ctx, client, conn, key, account.AccountID(), now); err == nil {
+				values, err = client.HGetAll(ctx, key).Result()
+			}
+		}
+		if err != nil {
+			utils.Log.WithContext(ctx).Warnf("Metering usage %s failed: %v", key, err)
+		} else {
+			fromRedis = true
+			for meter, value := range values {
+				if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+					used[meter] = n
+				}
+			}
+		}
+	}
+	if !fromRedis && conn != nil {
+		var records []UsageRecord
+		if err := conn.WithContext(ctx).
+			Where("user_id = ? AND period = ?", account.AccountID(), period(now)).
+			Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			used[r.Meter] = r.Quantity
+		}
+	}
+
+	plan := planName(account.PlanName())
+	meters := make([]string, 0, len(used))
+	for meter := range config.Plans[plan] {
+		meters = append(meters, meter)
+	}
+	for meter := range used {
+		if !slices.Contains(meters, meter) {
+			meters = append(meters, meter)
+		}
+	}
+	slices.Sort(meters)
+
+	report := &Report{
+		Plan:        plan,
+		PeriodStart: period(now),
+		ResetsAt:    nextPeriod(now),
+		Meters:      make([]MeterUsage, 0, len(meters)),
+	}
+	for _, meter := range meters {
+		usage := MeterUsage{Meter: meter, Used: used[meter]}
+		if quota, limited := Quota(plan, meter); limited {
+			remaining := max(quota-usage.Used, 0)
+			usage.Limit, usage.Remaining = &quota, &remaining
+		}
+		report.Meters = append(report.Meters, usage)
+	}
+	return report, nil
+}
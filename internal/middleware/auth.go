@@ -3,7 +3,9 @@ package middleware
 import (
 	"strings"
 
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	model "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/models"
 	service "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/services"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
 
@@ -16,42 +18,49 @@ func Auth(userService service.UserService, requiredRights ...string) fiber.Handl
 		token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
 
 		if token == "" {
-			return fiber.NewError(fiber.StatusUnauthorized, "Please authenticate")
+			return apperror.ErrUnauthenticated
 		}
 
-		userID, err := utils.VerifyToken(token, config.JWTSecret, config.TokenTypeAccess)
+		userID, issuedAt, err := utils.VerifyTokenIssuedAt(token, config.JWTSecret, config.TokenTypeAccess)
 		if err != nil {
-			return fiber.NewError(fiber.StatusUnauthorized, "Please authenticate")
+			return apperror.ErrUnauthenticated
 		}
 
 		user, err := userService.GetOne(c, userID)
 		if err != nil || user == nil {
-			return fiber.NewError(fiber.StatusUnauthorized, "Please authenticate")
+			return apperror.ErrUnauthenticated
+		}
+		// dicabut oleh ganti password
+		if user.TokensValidAfter != nil && issuedAt.Before(*user.TokensValidAfter) {
+			return apperror.ErrUnauthenticated
+		}
+		if user.Status == model.UserStatusSuspended {
+			return apperror.ErrSuspended
 		}
 
 		c.Locals("user", user)
 
-		// if len(requiredRights) > 0 {
-		// 	userRights, hasRights := config.RoleRights[user.Role]
-		// 	if (!hasRights || !hasAllRights(userRights, requiredRights)) && c.Params("userId") != userID {
-		// 		return fiber.NewError(fiber.StatusForbidden, "You don't have permission to access this resource")
-		// 	}
-		// }
+		if len(requiredRights) > 0 {
+			userRights, hasRights := config.RoleRights[user.Role]
+			if !hasRights || !hasAllRights(userRights, requiredRights) {
+				return apperror.ErrForbidden
+			}
+		}
 
 		return c.Next()
 	}
 }
 
-// func hasAllRights(userRights, requiredRights []string) bool {
-// 	rightSet := make(map[string]struct{}, len(userRights))
-// 	for _, right := range userRights {
-// 		rightSet[right] = struct{}{}
-// 	}
-
-// 	for _, right := range requiredRights {
-// 		if _, exists := rightSet[right]; !exists {
-// 			return false
-// 		}
-// 	}
-// 	return true
-// }
+func hasAllRights(userRights, requiredRights []string) bool {
+	rightSet := make(map[string]struct{}, len(userRights))
+	for _, right := range userRights {
+		rightSet[right] = struct{}{}
+	}
+
+	for _, right := range requiredRights {
+		if _, exists := rightSet[right]; !exists {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// multipartEnvelope is the room left for the multipart boundaries and fields
+// around an uploaded file.
+const multipartEnvelope = 1 << 20
+
+// uploadLimits are the body limits of the upload routes, "METHOD /path" ->
+// bytes, registered by AllowUpload.
+var uploadLimits sync.Map
+
+// BodyLimit rejects request bodies over limit bytes with 413, except on the
+// routes registered with AllowUpload which get their own limit.
+//
+// fasthttp reads the body before routing, so fiber.Config.BodyLimit stays at
+// the largest limit (see config.FiberConfig) and the default is enforced here.
+func BodyLimit(limit int64) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		allowed := limit
+		if v, ok := uploadLimits.Load(routeKey(c.Method(), c.Path())); ok {
+			allowed = v.(int64)
+		}
+
+		// multipart sudah di-parse fasthttp, Body() bisa kosong: cek Content-Length juga
+		size := max(int64(c.Request().Header.ContentLength()), int64(len(c.Request().Body())))
+		if size > allowed {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		return c.Next()
+	}
+}
+
+// AllowUpload lets the multipart route method + path of router accept a file
+// up to maxFileSize, ex:
+//
+//	route.Post("/", middleware.Auth(u), ctrl.Upload)
+//	middleware.AllowUpload(route, fiber.MethodPost, "/", config.UploadMaxSize)
+//
+// Only static paths are supported.
+func AllowUpload(router fiber.Router, method, path string, maxFileSize int64) {
+	if group, ok := router.(*fiber.Group); ok {
+		path = group.Prefix + path
+	}
+	uploadLimits.Store(routeKey(method, path), maxFileSize+multipartEnvelope)
+}
+
+// routeKey matches fiber's non-strict routing, "/api/files/" == "/api/files".
+func routeKey(method, path string) string {
+	if path = strings.TrimRight(path, "/"); path == "" {
+		path = "/"
+	}
+	return method + " " + path
+}
@@ -3,24 +3,35 @@ package middleware
 import (
 	"time"
 
-	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/ratelimit"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 )
 
+// LimiterConfig limits failed auth attempts per IP.
 func LimiterConfig() fiber.Handler {
-	return limiter.New(limiter.Config{
-		Max:        20,
-		Expiration: 15 * time.Minute,
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).
-				JSON(response.Common{
-					Code:    fiber.StatusTooManyRequests,
-					Status:  "error",
-					Message: "Too many requests, please try again later",
-				})
-		},
-		SkipSuccessfulRequests: true,
+	return ratelimit.New(ratelimit.Policy{
+		Name:           "auth",
+		Limit:          20,
+		Window:         15 * time.Minute,
+		Key:            ratelimit.ByIP,
+		SkipSuccessful: true,
+		FailOpen:       config.RateLimitFailOpen,
+	})
+}
+
+// APILimiter is the default policy of every /api request, RATE_LIMIT_REQUESTS
+// per RATE_LIMIT_WINDOW_SECONDS per client IP (0 disables it). It runs before
+// middleware.Auth, routes add ByUser policies of their own. Behind a proxy
+// set TRUSTED_PROXIES, otherwise every client shares the proxy's bucket.
+func APILimiter() fiber.Handler {
+	return ratelimit.New(ratelimit.Policy{
+		Name:      "api",
+		Limit:     config.RateLimitRequests,
+		Window:    config.RateLimitWindow,
+		Algorithm: ratelimit.TokenBucket,
+		Key:       ratelimit.ByIP,
+		FailOpen:  config.RateLimitFailOpen,
 	})
 }
@@ -1,13 +1,44 @@
 package middleware
 
 import (
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/sirupsen/logrus"
 )
 
 func LoggerConfig() fiber.Handler {
+	if config.LogFormat == "json" {
+		return accessLog
+	}
 	return logger.New(logger.Config{
-		Format:     "${time} ${method} ${status} ${path} in ${latency}\n",
+		Format:     "${time} ${respHeader:X-Request-ID} ${method} ${status} ${path} in ${latency}\n",
 		TimeFormat: "15:04:05.00",
 	})
 }
+
+// accessLog writes the access log through utils.Log, one JSON line with the
+// request id / user id like the rest of the logs.
+func accessLog(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	if err != nil {
+		// error baru dirender error handler setelah middleware selesai
+		status = utils.ErrorStatus(err)
+	}
+
+	utils.Log.WithContext(c.Context()).WithFields(logrus.Fields{
+		"method":     c.Method(),
+		"path":       c.Path(),
+		"status":     status,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"ip":         c.IP(),
+	}).Info("Request")
+	return err
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Negotiate lets clients talk MessagePack or CBOR instead of JSON.
+//
+// Request bodies are converted to JSON by Content-Type before the handler,
+// so BodyParser / validation.Bind see JSON. JSON responses (response.Success,
+// SuccessWithPaginate, errors) are re-encoded to the best Accept match, other
+// content (files, exports, problem+json) is sent as is. A client accepting
+// none of response.Formats gets 406. Errors are returned as is, response.Error
+// encodes them the same way once the error handler renders them.
+func Negotiate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if mediaType := response.MediaType(c.Get(fiber.HeaderContentType)); len(c.Body()) > 0 &&
+			(mediaType == response.MIMEMsgPack || mediaType == response.MIMECBOR) {
+			body, err := response.Decode(mediaType, c.Body())
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+			}
+			c.Request().SetBody(body)
+			c.Request().Header.SetContentType(fiber.MIMEApplicationJSON)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		if c.Context().IsBodyStream() ||
+			response.MediaType(string(c.Response().Header.ContentType())) != fiber.MIMEApplicationJSON {
+			return nil
+		}
+		c.Vary(fiber.HeaderAccept)
+		if c.Response().StatusCode() == fiber.StatusNotModified {
+			return nil
+		}
+
+		format := c.Accepts(response.Formats...)
+		if format == "" {
+			// handler sudah jalan, hanya representasinya yang ditolak
+			c.Response().ResetBody()
+			return fiber.NewError(fiber.StatusNotAcceptable, "Use application/json, application/msgpack or application/cbor")
+		}
+		if format == fiber.MIMEApplicationJSON {
+			return nil
+		}
+
+		body, err := response.Encode(format, c.Response().Body())
+		if err != nil {
+			return err
+		}
+		c.Response().SetBodyRaw(body)
+		c.Response().Header.SetContentType(format)
+		return nil
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/requestid"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestID keeps the X-Request-ID sent by the client (or a proxy) or
+// generates one, echoes it in the response and stores it for
+// requestid.From(c.Context()), utils.Log and outbound calls.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = utils.NewUUID().String()
+		}
+
+		c.Locals(requestid.Key, id)
+		c.SetUserContext(requestid.With(c.UserContext(), id))
+		c.Set(requestid.Header, id)
+		return c.Next()
+	}
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadYourWrites gives every request its own session so reads issued after
+// a write in the same request are served by the primary.
+func ReadYourWrites() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(database.SessionKey{}, &database.Session{})
+		return c.Next()
+	}
+}
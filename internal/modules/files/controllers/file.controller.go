@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"path"
+
+	service "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/files/services"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FileController struct {
+	FileService service.FileService
+}
+
+func NewFileController(fileService service.FileService) *FileController {
+	return &FileController{
+		FileService: fileService,
+	}
+}
+
+func (f *FileController) Upload(c *fiber.Ctx) error {
+	result, err := f.FileService.Upload(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).
+		JSON(response.Success{
+			Code:    fiber.StatusCreated,
+			Status:  "success",
+			Message: "Upload file successfully",
+			Data:    result,
+		})
+}
+
+func (f *FileController) SignedURL(c *fiber.Ctx) error {
+	result, err := f.FileService.SignedURL(c, c.Query("key"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Get file url successfully",
+			Data:    result,
+		})
+}
+
+func (f *FileController) Delete(c *fiber.Ctx) error {
+	if err := f.FileService.Delete(c, c.Query("key")); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Common{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Delete file successfully",
+		})
+}
+
+func (f *FileController) Download(c *fiber.Ctx) error {
+	r, obj, err := f.FileService.Open(c, c.Params("*"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		return err
+	}
+
+	// selalu attachment + sandbox, file upload user tidak boleh jalan di origin API
+	c.Set(fiber.HeaderContentType, storage.SafeContentType(obj.ContentType))
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+path.Base(obj.Key)+`"`)
+	c.Set(fiber.HeaderContentSecurityPolicy, "sandbox; default-src 'none'")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	// fasthttp menutup reader setelah body terkirim
+	return c.Status(fiber.StatusOK).SendStream(r, int(obj.Size))
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
+)
+
+// === DTO Structs ===
+
+type FileDTO struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type SignedURLDTO struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// === Mapper Functions ===
+
+func ToFileDTO(o storage.Object, url string, expiresAt time.Time) FileDTO {
+	return FileDTO{
+		Key:         o.Key,
+		Size:        o.Size,
+		ContentType: o.ContentType,
+		URL:         url,
+		ExpiresAt:   expiresAt,
+	}
+}
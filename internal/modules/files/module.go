@@ -0,0 +1,34 @@
+package files
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	sFile "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/files/services"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	rUser "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/repositories"
+	sUser "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/services"
+)
+
+type FileModule struct{}
+
+func (FileModule) RegisterRoutes(router fiber.Router, db *gorm.DB, rdb *redis.Client, validate *validator.Validate) {
+	// cached: middleware.Auth loads the user on every request
+	userRepo := rUser.NewCachedUserRepository(db, rdb)
+
+	driver, err := storage.Default()
+	if err != nil {
+		// cmd/api sudah berhenti saat startup (setupStorage)
+		utils.Log.Errorf("File routes not registered: %v", err)
+		return
+	}
+
+	fileService := sFile.NewFileService(driver)
+	userService := sUser.NewUserService(userRepo, driver, validate)
+
+	FileRoutes(router, userService, fileService)
+}
@@ -0,0 +1,24 @@
+package files
+
+import (
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	m "github.com/hafizhproject45/Golang-Boilerplate.git/internal/middleware"
+	controller "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/files/controllers"
+	file "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/files/services"
+	user "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func FileRoutes(v1 fiber.Router, u user.UserService, s file.FileService) {
+	ctrl := controller.NewFileController(s)
+
+	route := v1.Group("/files")
+
+	route.Post("/", m.Auth(u), ctrl.Upload)
+	m.AllowUpload(route, fiber.MethodPost, "/", config.UploadMaxSize)
+	route.Get("/url", m.Auth(u), ctrl.SignedURL)
+	route.Delete("/", m.Auth(u), ctrl.Delete)
+	// signed download of the local driver, no auth: the signature is the credential
+	route.Get("/raw/*", ctrl.Download)
+}
@@ -0,0 +1,10 @@
+package service
+
+import "github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+
+// files error catalogue
+var (
+	ErrFileNotFound     = apperror.Define(apperror.NotFound, "file_not_found", "File not found")
+	ErrInvalidKey       = apperror.Define(apperror.BadRequest, "file_invalid_key", "Invalid key")
+	ErrInvalidSignature = apperror.Define(apperror.Forbidden, "file_invalid_signature", "Invalid or expired signature")
+)
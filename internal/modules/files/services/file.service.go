@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/files/dto"
+	mUser "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/models"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type FileService interface {
+	Upload(ctx *fiber.Ctx) (*dto.FileDTO, error)
+	SignedURL(ctx *fiber.Ctx, key string) (*dto.SignedURLDTO, error)
+	Delete(ctx *fiber.Ctx, key string) error
+	Open(ctx *fiber.Ctx, key, expires, signature string) (io.ReadCloser, *storage.Object, error)
+}
+
+type fileService struct {
+	Log     *logrus.Logger
+	Storage storage.Driver
+}
+
+func NewFileService(driver storage.Driver) FileService {
+	return &fileService{
+		Log:     utils.Log,
+		Storage: driver,
+	}
+}
+
+func (s fileService) Upload(c *fiber.Ctx) (*dto.FileDTO, error) {
+	user, err := currentUser(c)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := storage.Upload(c.Context(), c, s.Storage, ownerPrefix(user), storage.UploadOptions{})
+	if err != nil {
+		var fiberErr *fiber.Error
+		if !errors.As(err, &fiberErr) {
+			s.Log.WithContext(c.Context()).Errorf("Failed to upload file: %+v", err)
+		}
+		return nil, err
+	}
+
+	url, err := s.Storage.SignedURL(c.Context(), obj.Key, config.StorageURLTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.ToFileDTO(*obj, url, time.Now().Add(config.StorageURLTTL))
+	return &result, nil
+}
+
+func (s fileService) SignedURL(c *fiber.Ctx, key string) (*dto.SignedURLDTO, error) {
+	key, err := s.ownedKey(c, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.Storage.Stat(c.Context(), key); err != nil {
+		return nil, s.notFound(c, err)
+	}
+
+	url, err := s.Storage.SignedURL(c.Context(), key, config.StorageURLTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.SignedURLDTO{URL: url, ExpiresAt: time.Now().Add(config.StorageURLTTL)}, nil
+}
+
+func (s fileService) Delete(c *fiber.Ctx, key string) error {
+	key, err := s.ownedKey(c, key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Storage.Stat(c.Context(), key); err != nil {
+		return s.notFound(c, err)
+	}
+	if err := s.Storage.Delete(c.Context(), key); err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to delete file: %+v", err)
+		return err
+	}
+	return nil
+}
+
+// Open serves signed downloads of the local driver, S3 URLs point to the bucket directly.
+func (s fileService) Open(c *fiber.Ctx, key, expires, signature string) (io.ReadCloser, *storage.Object, error) {
+	local, ok := s.Storage.(*storage.LocalDriver)
+	if !ok || !local.Verify(key, expires, signature) {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	r, obj, err := local.Get(c.Context(), key)
+	if err != nil {
+		return nil, nil, s.notFound(c, err)
+	}
+	return r, obj, nil
+}
+
+// ownedKey only lets users touch files under their own upload prefix.
+func (s fileService) ownedKey(c *fiber.Ctx, key string) (string, error) {
+	user, err := currentUser(c)
+	if err != nil {
+		return "", err
+	}
+
+	key, err = storage.CleanKey(key)
+	if err != nil {
+		return "", ErrInvalidKey
+	}
+	if !strings.HasPrefix(key, ownerPrefix(user)+"/") {
+		return "", ErrFileNotFound
+	}
+	return key, nil
+}
+
+func (s fileService) notFound(c *fiber.Ctx, err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrFileNotFound
+	}
+	s.Log.WithContext(c.Context()).Errorf("Failed to stat file: %+v", err)
+	return err
+}
+
+func currentUser(c *fiber.Ctx) (*mUser.User, error) {
+	user, ok := c.Locals("user").(*mUser.User)
+	if !ok || user == nil {
+		return nil, apperror.ErrUnauthenticated
+	}
+	return user, nil
+}
+
+func ownerPrefix(user *mUser.User) string {
+	return fmt.Sprintf("uploads/%d", user.Id)
+}
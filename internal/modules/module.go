@@ -1,11 +1,20 @@
 package modules
 
 import (
+	"context"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type Module interface {
-	RegisterRoutes(router fiber.Router, db *gorm.DB, validate *validator.Validate)
+	RegisterRoutes(router fiber.Router, db *gorm.DB, rdb *redis.Client, validate *validator.Validate)
+}
+
+// Background is implemented by modules with background loops. main starts
+// them once (not in prefork children), they stop when ctx is done.
+type Background interface {
+	RunBackground(ctx context.Context, db *gorm.DB, rdb *redis.Client)
 }
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/jobs"
+	service "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/services"
+	validation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/validations"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+	sharedValidation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImportController struct {
+	ImportService service.ImportService
+}
+
+func NewImportController(importService service.ImportService) *ImportController {
+	return &ImportController{
+		ImportService: importService,
+	}
+}
+
+func (i *ImportController) Import(c *fiber.Ctx) error {
+	req, err := sharedValidation.Bind[validation.Import](c)
+	if err != nil {
+		return err
+	}
+
+	job, err := i.ImportService.Import(c, req)
+	if err != nil {
+		return err
+	}
+
+	switch job.Status {
+	case jobs.StatusFailed:
+		return fiber.NewError(fiber.StatusInternalServerError, "Import failed")
+	case jobs.StatusDone:
+		return c.Status(fiber.StatusOK).
+			JSON(response.Success{
+				Code:    fiber.StatusOK,
+				Status:  "success",
+				Message: "Import users successfully",
+				Data:    job,
+			})
+	}
+
+	c.Location("/api/users/import/" + job.ID)
+	return c.Status(fiber.StatusAccepted).
+		JSON(response.Success{
+			Code:    fiber.StatusAccepted,
+			Status:  "success",
+			Message: "Import started, poll the job for its progress",
+			Data:    job,
+		})
+}
+
+func (i *ImportController) GetJob(c *fiber.Ctx) error {
+	job, err := i.ImportService.GetJob(c, c.Params("jobId"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Get import successfully",
+			Data:    job,
+		})
+}
+
+// Report redirects to a short lived signed URL of the CSV report.
+func (i *ImportController) Report(c *fiber.Ctx) error {
+	url, err := i.ImportService.ReportURL(c, c.Params("jobId"))
+	if err != nil {
+		return err
+	}
+
+	return c.Redirect(url, fiber.StatusFound)
+}
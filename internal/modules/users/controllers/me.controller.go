@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/dto"
+	service "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/services"
+	validation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/validations"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/patch"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+	sharedValidation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MeController struct {
+	MeService service.MeService
+}
+
+func NewMeController(meService service.MeService) *MeController {
+	return &MeController{
+		MeService: meService,
+	}
+}
+
+func (m *MeController) GetMe(c *fiber.Ctx) error {
+	result, err := m.MeService.GetMe(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Get profile successfully",
+			Data:    dto.ToUserDetailDTO(*result),
+		})
+}
+
+func (m *MeController) UpdateMe(c *fiber.Ctx) error {
+	req := new(validation.UpdateMe)
+
+	err := patch.Bind(c, req, func() (any, error) {
+		me, err := m.MeService.GetMe(c)
+		if err != nil {
+			return nil, err
+		}
+		return dto.ToUserDetailDTO(*me), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	result, err := m.MeService.UpdateMe(c, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Update profile successfully",
+			Data:    dto.ToUserDetailDTO(*result),
+		})
+}
+
+func (m *MeController) ChangePassword(c *fiber.Ctx) error {
+	req, err := sharedValidation.Bind[validation.ChangePassword](c)
+	if err != nil {
+		return err
+	}
+
+	if err := m.MeService.ChangePassword(c, req); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Common{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Change password successfully",
+		})
+}
+
+func (m *MeController) ChangeEmail(c *fiber.Ctx) error {
+	req, err := sharedValidation.Bind[validation.ChangeEmail](c)
+	if err != nil {
+		return err
+	}
+
+	if err := m.MeService.RequestEmailChange(c, req); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).
+		JSON(response.Common{
+			Code:    fiber.StatusAccepted,
+			Status:  "success",
+			Message: "Verification link sent to the new email address",
+		})
+}
+
+func (m *MeController) VerifyEmail(c *fiber.Ctx) error {
+	result, err := m.MeService.ConfirmEmailChange(c, c.Query("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Email changed successfully",
+			Data:    dto.ToUserDetailDTO(*result),
+		})
+}
+
+func (m *MeController) DeleteMe(c *fiber.Ctx) error {
+	req, err := sharedValidation.Bind[validation.DeleteMe](c)
+	if err != nil {
+		return err
+	}
+
+	purgeAt, err := m.MeService.DeleteMe(c, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Account deleted, it will be purged permanently after the grace period",
+			Data:    fiber.Map{"purge_at": purgeAt.Format(time.RFC3339)},
+		})
+}
+
+func (m *MeController) UploadAvatar(c *fiber.Ctx) error {
+	result, err := m.MeService.UploadAvatar(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Upload avatar successfully",
+			Data:    dto.ToUserDetailDTO(*result),
+		})
+}
+
+func (m *MeController) RemoveAvatar(c *fiber.Ctx) error {
+	result, err := m.MeService.RemoveAvatar(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Remove avatar successfully",
+			Data:    dto.ToUserDetailDTO(*result),
+		})
+}
+
+func (m *MeController) Usage(c *fiber.Ctx) error {
+	result, err := m.MeService.Usage(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Get usage successfully",
+			Data:    result,
+		})
+}
+
+// Avatar redirects to a short lived signed URL of the uploaded avatar.
+func (m *MeController) Avatar(c *fiber.Ctx) error {
+	params, err := sharedValidation.Bind[validation.ByID](c)
+	if err != nil {
+		return err
+	}
+
+	url, err := m.MeService.AvatarURL(c, params.ID)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, max-age=60")
+	return c.Redirect(url, fiber.StatusFound)
+}
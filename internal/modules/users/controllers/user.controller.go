@@ -2,12 +2,15 @@ package controller
 
 import (
 	"math"
-	"strconv"
 
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/export"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/httpcache"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/dto"
 	service "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/services"
 	validation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/validations"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/patch"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+	sharedValidation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -23,10 +26,17 @@ func NewUserController(userService service.UserService) *UserController {
 }
 
 func (u *UserController) GetAll(c *fiber.Ctx) error {
-	query := &validation.Query{
-		Page:   c.QueryInt("page", 1),
-		Limit:  c.QueryInt("limit", 10),
-		Search: c.Query("search", ""),
+	query, err := sharedValidation.Bind[validation.Query](c)
+	if err != nil {
+		return err
+	}
+
+	lastModified, count, err := u.UserService.Freshness(c, query)
+	if err != nil {
+		return err
+	}
+	if httpcache.Fresh(c, httpcache.Weak(c, lastModified, count), lastModified) {
+		return httpcache.NotModified(c)
 	}
 
 	result, totalResults, err := u.UserService.GetAll(c, query)
@@ -49,33 +59,49 @@ func (u *UserController) GetAll(c *fiber.Ctx) error {
 		})
 }
 
-func (u *UserController) GetOne(c *fiber.Ctx) error {
-	param := c.Params("id")
+// Export takes the GetAll filters and returns every matching user as a
+// download, see export.Write for format, columns and lang.
+func (u *UserController) Export(c *fiber.Ctx) error {
+	query, err := sharedValidation.Bind[validation.Query](c)
+	if err != nil {
+		return err
+	}
+
+	rows, err := u.UserService.Export(c, query)
+	if err != nil {
+		return err
+	}
+
+	return export.Write(c, "users", dto.UserExportColumns, rows)
+}
 
-	id, err := strconv.Atoi(param)
+func (u *UserController) GetOne(c *fiber.Ctx) error {
+	params, err := sharedValidation.Bind[validation.ByID](c)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid Id")
+		return err
 	}
 
-	result, err := u.UserService.GetOne(c, uint(id))
+	result, err := u.UserService.GetOne(c, params.ID)
 	if err != nil {
 		return err
 	}
+	if httpcache.Fresh(c, httpcache.Strong(c, result.Id, result.UpdatedAt), result.UpdatedAt) {
+		return httpcache.NotModified(c)
+	}
 
 	return c.Status(fiber.StatusOK).
 		JSON(response.Success{
 			Code:    fiber.StatusOK,
 			Status:  "success",
 			Message: "Get user successfully",
-			Data:    dto.ToUserListDTO(*result),
+			Data:    dto.ToUserDetailDTO(*result),
 		})
 }
 
 func (u *UserController) CreateOne(c *fiber.Ctx) error {
-	req := new(validation.Create)
-
-	if err := c.BodyParser(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	req, err := sharedValidation.Bind[validation.Create](c)
+	if err != nil {
+		return err
 	}
 
 	result, err := u.UserService.CreateOne(c, req)
@@ -88,24 +114,32 @@ func (u *UserController) CreateOne(c *fiber.Ctx) error {
 			Code:    fiber.StatusCreated,
 			Status:  "success",
 			Message: "Create user successfully",
-			Data:    dto.ToUserListDTO(*result),
+			Data:    dto.ToUserDetailDTO(*result),
 		})
 }
 
 func (u *UserController) UpdateOne(c *fiber.Ctx) error {
-	req := new(validation.Update)
-	param := c.Params("id")
-
-	id, err := strconv.Atoi(param)
+	params, err := sharedValidation.Bind[validation.ByID](c)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid Id")
+		return err
 	}
 
-	if err := c.BodyParser(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	// unique_except_self pada Update mengecualikan row ini
+	sharedValidation.Self(c, params.ID)
+
+	req := new(validation.Update)
+	err = patch.Bind(c, req, func() (any, error) {
+		current, err := u.UserService.GetOne(c, params.ID)
+		if err != nil {
+			return nil, err
+		}
+		return dto.ToUserDetailDTO(*current), nil
+	})
+	if err != nil {
+		return err
 	}
 
-	result, err := u.UserService.UpdateOne(c, req, uint(id))
+	result, err := u.UserService.UpdateOne(c, req, params.ID)
 	if err != nil {
 		return err
 	}
@@ -115,19 +149,17 @@ func (u *UserController) UpdateOne(c *fiber.Ctx) error {
 			Code:    fiber.StatusOK,
 			Status:  "success",
 			Message: "Update user successfully",
-			Data:    dto.ToUserListDTO(*result),
+			Data:    dto.ToUserDetailDTO(*result),
 		})
 }
 
 func (u *UserController) DeleteOne(c *fiber.Ctx) error {
-	param := c.Params("id")
-
-	id, err := strconv.Atoi(param)
+	params, err := sharedValidation.Bind[validation.ByID](c)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid Id")
+		return err
 	}
 
-	if err := u.UserService.DeleteOne(c, uint(id)); err != nil {
+	if err := u.UserService.DeleteOne(c, params.ID); err != nil {
 		return err
 	}
 
@@ -3,6 +3,7 @@ package dto
 import (
 	"time"
 
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/export"
 	model "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/models"
 )
 
@@ -11,20 +12,34 @@ import (
 type UserListDTO struct {
 	Id        uint      `json:"id"`
 	Name      string    `json:"name"`
+	Email     *string   `json:"email"` // null for older users without one
+	Status    string    `json:"status"`
+	AvatarURL *string   `json:"avatar_url"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Highlight string    `json:"highlight,omitempty"` // HTML, only <mark> is not escaped
 }
 
 type UserDetailDTO struct {
 	UserListDTO
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Locale          string     `json:"locale"`
+	Timezone        string     `json:"timezone"`
+	Plan            string     `json:"plan"`
 }
 
 // === Mapper Functions ===
 
 func ToUserListDTO(m model.User) UserListDTO {
 	return UserListDTO{
-		Id:   m.Id,
-		Name: m.Name,
+		Id:        m.Id,
+		Name:      m.Name,
+		Email:     m.Email,
+		Status:    string(m.Status),
+		AvatarURL: m.AvatarURL,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+		Highlight: m.Highlight,
 	}
 }
 
@@ -35,3 +50,28 @@ func ToUserListDTOs(m []model.User) []UserListDTO {
 	}
 	return result
 }
+
+func ToUserDetailDTO(m model.User) UserDetailDTO {
+	return UserDetailDTO{
+		UserListDTO:     ToUserListDTO(m),
+		EmailVerifiedAt: m.EmailVerifiedAt,
+		Locale:          m.Locale,
+		Timezone:        m.Timezone,
+		Plan:            m.Plan,
+	}
+}
+
+// === Export Columns ===
+
+var UserExportColumns = []export.Column[model.User]{
+	{Key: "id", Headers: map[string]string{"en": "ID", "id": "ID"}, Value: func(m model.User) any { return m.Id }},
+	{Key: "name", Headers: map[string]string{"en": "Name", "id": "Nama"}, Value: func(m model.User) any { return m.Name }},
+	{Key: "email", Headers: map[string]string{"en": "Email", "id": "Email"}, Value: func(m model.User) any { return m.Email }},
+	{Key: "status", Headers: map[string]string{"en": "Status", "id": "Status"}, Value: func(m model.User) any { return m.Status }},
+	{Key: "email_verified_at", Headers: map[string]string{"en": "Email Verified At", "id": "Email Diverifikasi"}, Value: func(m model.User) any { return m.EmailVerifiedAt }},
+	{Key: "avatar_url", Headers: map[string]string{"en": "Avatar URL", "id": "URL Avatar"}, Value: func(m model.User) any { return m.AvatarURL }},
+	{Key: "locale", Headers: map[string]string{"en": "Locale", "id": "Bahasa"}, Value: func(m model.User) any { return m.Locale }},
+	{Key: "timezone", Headers: map[string]string{"en": "Timezone", "id": "Zona Waktu"}, Value: func(m model.User) any { return m.Timezone }},
+	{Key: "created_at", Headers: map[string]string{"en": "Created At", "id": "Dibuat"}, Value: func(m model.User) any { return m.CreatedAt }},
+	{Key: "updated_at", Headers: map[string]string{"en": "Updated At", "id": "Diperbarui"}, Value: func(m model.User) any { return m.UpdatedAt }},
+}
@@ -1,15 +1,74 @@
 package model
 
 import (
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+type UserStatus string
+
+const (
+	UserStatusPending   UserStatus = "pending"
+	UserStatusActive    UserStatus = "active"
+	UserStatusSuspended UserStatus = "suspended"
+)
+
 type User struct {
-	Id        uint   `gorm:"primaryKey"`
-	Name      string `gorm:"not null"`
+	Id    uint    `gorm:"primaryKey"`
+	Name  string  `gorm:"not null"`
+	Email *string `gorm:"type:varchar(255)"` // stored lower-case (chk_users_email_lower), unique among active users, NULL for older users
+
+	EmailVerifiedAt *time.Time
+
+	// json:"-" keeps the hash out of the repository cache,
+	// load it with a modifier (cache bypass) when it is needed
+	PasswordHash string `gorm:"not null;default:''" json:"-"`
+	// access tokens issued before it are rejected, set by a password change
+	TokensValidAfter *time.Time
+
+	Status    UserStatus `gorm:"type:varchar(20);not null;default:pending"`
+	Role      string     `gorm:"type:varchar(20);not null;default:user"` // key of config.RoleRights
+	AvatarURL *string
+	AvatarKey *string // set when the avatar was uploaded to storage
+	Locale    string  `gorm:"type:varchar(35);not null;default:en"`
+	Timezone  string  `gorm:"type:varchar(64);not null;default:UTC"`
+	Plan      string  `gorm:"type:varchar(32);not null"` // quotas of config.Plans, config.DefaultPlan on creation
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	PurgeAt   *time.Time     `json:"-"` // set only when users delete their own account, see PurgeDeleted
+
+	// filled only by repository.Search with Highlight
+	Highlight string `gorm:"->;-:migration" json:"-"`
+}
+
+// PreferredLocale is used for validation messages when the request has no Accept-Language.
+func (u User) PreferredLocale() string {
+	return u.Locale
+}
+
+// CacheScope keys per user response cache entries, see httpcache.PerUser.
+func (u User) CacheScope() string {
+	return strconv.FormatUint(uint64(u.Id), 10)
+}
+
+// LogUserID is added as user_id to log entries of authenticated requests.
+func (u User) LogUserID() string {
+	return strconv.FormatUint(uint64(u.Id), 10)
+}
+
+// RateLimitKey limits authenticated requests per user, see ratelimit.ByUser.
+func (u User) RateLimitKey() string {
+	return strconv.FormatUint(uint64(u.Id), 10)
+}
+
+// AccountID and PlanName meter the usage of the user, see metering.Meter.
+func (u User) AccountID() uint {
+	return u.Id
+}
+
+func (u User) PlanName() string {
+	return u.Plan
 }
@@ -1,20 +1,53 @@
 package users
 
 import (
+	"context"
+	"time"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
 	rUser "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/repositories"
 	sUser "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/services"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
 )
 
 type UserModule struct{}
 
-func (UserModule) RegisterRoutes(router fiber.Router, db *gorm.DB, validate *validator.Validate) {
+func (UserModule) RegisterRoutes(router fiber.Router, db *gorm.DB, rdb *redis.Client, validate *validator.Validate) {
+	userRepo := rUser.NewCachedUserRepository(db, rdb)
+
+	driver, err := storage.Default()
+	if err != nil {
+		// cmd/api sudah berhenti saat startup (setupStorage)
+		utils.Log.Errorf("User routes not registered: %v", err)
+		return
+	}
+
+	userService := sUser.NewUserService(userRepo, driver, validate)
+	meService := sUser.NewMeService(userRepo, rdb, driver, validate)
+	importService := sUser.NewImportService(userRepo, rdb, driver, validate)
+
+	UserRoutes(router, userService, meService, importService)
+}
+
+// RunBackground purges the accounts deleted by their users once their grace
+// period is over.
+func (UserModule) RunBackground(ctx context.Context, db *gorm.DB, rdb *redis.Client) {
 	userRepo := rUser.NewUserRepository(db)
 
-	userService := sUser.NewUserService(userRepo, validate)
+	// storage untuk menghapus avatar user yang di-purge
+	driver, err := storage.Default()
+	if err != nil {
+		// cmd/api sudah berhenti saat startup (setupStorage)
+		utils.Log.Errorf("User purge not started: %v", err)
+		return
+	}
+	// purge tidak memakai validator
+	meService := sUser.NewMeService(userRepo, rdb, driver, nil)
 
-	UserRoutes(router, userService)
+	sUser.PurgeLoop(ctx, meService, time.Hour)
 }
@@ -1,22 +1,45 @@
 package repository
 
 import (
+	"sync"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
 	model "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/models"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/repository"
 
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
-	repository.BaseRepository[model.User]
+	repository.BaseRepository[model.User, uint]
 }
 
 type UserRepositoryImpl struct {
-	*repository.BaseRepositoryImpl[model.User]
+	*repository.BaseRepositoryImpl[model.User, uint]
 }
 
 func NewUserRepository(db *gorm.DB) UserRepository {
 	return &UserRepositoryImpl{
-		BaseRepositoryImpl: repository.NewBaseRepository[model.User](db),
+		BaseRepositoryImpl: repository.NewBaseRepository[model.User, uint](db),
 	}
 }
+
+var (
+	cachedOnce sync.Once
+	cachedRepo UserRepository
+)
+
+// NewCachedUserRepository returns the cached user repository shared by every
+// module (middleware.Auth loads the user on every request). It is built once,
+// so all modules invalidate through the same singleflight group; db and rdb
+// of later calls are ignored.
+func NewCachedUserRepository(db *gorm.DB, rdb *redis.Client) UserRepository {
+	cachedOnce.Do(func() {
+		cachedRepo = repository.NewCachedRepository[model.User, uint](NewUserRepository(db), rdb, repository.CacheOptions{
+			Prefix: "users",
+			TTL:    config.CacheTTL,
+		})
+	})
+	return cachedRepo
+}
@@ -1,20 +1,61 @@
 package users
 
 import (
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/httpcache"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/idempotency"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/metering"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/middleware"
 	controller "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/controllers"
 	user "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/services"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/ratelimit"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func UserRoutes(v1 fiber.Router, s user.UserService) {
+func UserRoutes(v1 fiber.Router, s user.UserService, me user.MeService, imp user.ImportService) {
 	ctrl := controller.NewUserController(s)
+	meCtrl := controller.NewMeController(me)
+	importCtrl := controller.NewImportController(imp)
+	idempotent := idempotency.New(idempotency.Options{TTL: config.IdempotencyTTL})
 
 	route := v1.Group("/users")
 
-	route.Get("/", ctrl.GetAll)
-	route.Post("/", ctrl.CreateOne)
-	route.Get("/:id", ctrl.GetOne)
-	route.Patch("/:id", ctrl.UpdateOne)
-	route.Delete("/:id", ctrl.DeleteOne)
+	// /me harus didaftarkan sebelum /:id
+	meRoute := route.Group("/me", middleware.Auth(s))
+	meRoute.Get("/", httpcache.New("private, no-cache"), meCtrl.GetMe)
+	meRoute.Patch("/", idempotent, meCtrl.UpdateMe)
+	meRoute.Delete("/", meCtrl.DeleteMe)
+	meRoute.Post("/password", meCtrl.ChangePassword)
+	meRoute.Post("/email", meCtrl.ChangeEmail)
+	meRoute.Put("/avatar", meCtrl.UploadAvatar)
+	middleware.AllowUpload(meRoute, fiber.MethodPut, "/avatar", config.UploadMaxSize)
+	meRoute.Delete("/avatar", meCtrl.RemoveAvatar)
+	meRoute.Get("/usage", meCtrl.Usage)
+	route.Get("/email/verify", meCtrl.VerifyEmail)
+	route.Get("/:id/avatar", meCtrl.Avatar)
+
+	route.Post("/import", middleware.Auth(s, "manageUsers"), ratelimit.New(ratelimit.Policy{
+		Name:  "users-import",
+		Limit: 5,
+		Key:   ratelimit.ByUser,
+	}), metering.Meter("imports", 1), importCtrl.Import)
+	middleware.AllowUpload(route, fiber.MethodPost, "/import", config.UploadMaxSize)
+	route.Get("/import/:jobId", middleware.Auth(s, "manageUsers"), importCtrl.GetJob)
+	route.Get("/import/:jobId/report", middleware.Auth(s, "manageUsers"), importCtrl.Report)
+
+	route.Get("/", middleware.Auth(s, "getUsers"), httpcache.New("private, no-cache"), httpcache.Cache(httpcache.CacheOptions{
+		TTL:   config.CacheTTL,
+		Tags:  []string{"users", "users:list"},
+		Scope: httpcache.PerUser,
+	}), ctrl.GetAll)
+	route.Get("/export", middleware.Auth(s, "manageUsers"), ctrl.Export)
+	route.Post("/", middleware.Auth(s, "manageUsers"), idempotent, ctrl.CreateOne)
+	route.Get("/:id", middleware.Auth(s, "getUsers"), httpcache.New("private, no-cache"), httpcache.Cache(httpcache.CacheOptions{
+		TTL:   config.CacheTTL,
+		Tags:  []string{"users", "users:{id}"},
+		Scope: httpcache.PerUser,
+	}), ctrl.GetOne)
+	route.Patch("/:id", middleware.Auth(s, "manageUsers"), idempotent, ctrl.UpdateOne)
+	route.Delete("/:id", middleware.Auth(s, "manageUsers"), ctrl.DeleteOne)
 }
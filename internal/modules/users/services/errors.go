@@ -0,0 +1,23 @@
+package service
+
+import "github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+
+// users error catalogue
+var (
+	ErrUserNotFound      = apperror.Define(apperror.NotFound, "user_not_found", "User not found")
+	ErrEmailTaken        = apperror.Define(apperror.Conflict, "email_taken", "Email already registered")
+	ErrIncorrectPassword = apperror.Define(apperror.Unauthorized, "incorrect_password", "Incorrect password")
+	ErrSameEmail         = apperror.Define(apperror.BadRequest, "email_unchanged", "New email must be different from the current one")
+	ErrInvalidToken      = apperror.Define(apperror.BadRequest, "invalid_token", "Invalid or expired token")
+	ErrMailFailed        = apperror.Define(apperror.BadGateway, "mail_failed", "Failed to send verification email")
+	ErrAvatarNotFound    = apperror.Define(apperror.NotFound, "avatar_not_found", "Avatar not found")
+
+	ErrImportMapping     = apperror.Define(apperror.BadRequest, "import_invalid_mapping", "Field mapping must be an object of header to column")
+	ErrImportFileType    = apperror.Define(apperror.UnsupportedMedia, "import_unsupported_file", "Only CSV and XLSX files are supported")
+	ErrImportInvalidFile = apperror.Define(apperror.BadRequest, "import_invalid_file", "Invalid spreadsheet")
+	ErrImportMissingCol  = apperror.Define(apperror.BadRequest, "import_missing_column", "Missing column")
+	ErrImportNotFound    = apperror.Define(apperror.NotFound, "import_not_found", "Import not found")
+	ErrReportNotFound    = apperror.Define(apperror.NotFound, "import_report_not_found", "Report not available")
+	ErrImportFileMissing = apperror.Define(apperror.BadRequest, "import_file_missing", "Field file must be a file")
+	ErrImportTooLarge    = apperror.Define(apperror.TooLarge, "import_file_too_large", "File is too large")
+)
@@ -0,0 +1,387 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/httpcache"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/jobs"
+	model "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/models"
+	repository "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/repositories"
+	validation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/validations"
+	baseRepo "github.com/hafizhproject45/Golang-Boilerplate.git/internal/repository"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/spreadsheet"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/secure"
+	sharedValidation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	importJobKind = "users.import"
+	// files with more rows than this are imported in the background
+	importSyncRows  = 200
+	importBatchSize = 500
+	// failed rows listed in the JSON result, the report has all of them
+	importMaxErrors = 100
+)
+
+// columns an import may overwrite on existing users, passwords are only
+// set for new users
+var importUpdatable = []string{"name", "status", "avatar_url", "locale", "timezone"}
+
+type ImportResult struct {
+	DryRun    bool              `json:"dry_run"`
+	Total     int               `json:"total"`
+	Inserted  int               `json:"inserted"`
+	Updated   int               `json:"updated"`
+	Failed    int               `json:"failed"`
+	Errors    []ImportRowResult `json:"errors"`
+	ReportKey string            `json:"report_key,omitempty"` // download via /users/import/:id/report
+}
+
+type ImportRowResult struct {
+	Line   int                `json:"line"`
+	Email  string             `json:"email"`
+	Status baseRepo.RowStatus `json:"status"`
+	Error  string             `json:"error,omitempty"`
+}
+
+type ImportService interface {
+	Import(ctx *fiber.Ctx, req *validation.Import) (*jobs.Job, error)
+	GetJob(ctx *fiber.Ctx, id string) (*jobs.Job, error)
+	ReportURL(ctx *fiber.Ctx, id string) (string, error)
+}
+
+type importService struct {
+	Log        *logrus.Logger
+	Validate   *validator.Validate
+	Repository repository.UserRepository
+	Redis      *redis.Client
+	Storage    storage.Driver
+}
+
+func NewImportService(repo repository.UserRepository, rdb *redis.Client, driver storage.Driver, validate *validator.Validate) ImportService {
+	return &importService{
+		Log:        utils.Log,
+		Validate:   validate,
+		Repository: repo,
+		Redis:      rdb,
+		Storage:    driver,
+	}
+}
+
+// Import reads the "file" multipart CSV / XLSX. Small files are imported
+// inline, big ones return a queued job to poll with GetJob.
+func (s importService) Import(c *fiber.Ctx, req *validation.Import) (*jobs.Job, error) {
+	me, ok := c.Locals("user").(*model.User)
+	if !ok || me == nil {
+		return nil, apperror.ErrUnauthenticated
+	}
+
+	mapping := spreadsheet.Mapping{}
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &mapping); err != nil {
+			return nil, ErrImportMapping
+		}
+	}
+
+	data, err := readFormFile(c, "file")
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, err := spreadsheet.Read(data, mapping)
+	if errors.Is(err, spreadsheet.ErrUnsupported) {
+		return nil, ErrImportFileType
+	}
+	if err != nil {
+		return nil, ErrImportInvalidFile.Msg("Invalid spreadsheet: " + err.Error())
+	}
+	for _, col := range []string{"name", "email"} {
+		if !slices.Contains(sheet.Columns, col) {
+			return nil, ErrImportMissingCol.Msg("Missing column "+col).With("column", col)
+		}
+	}
+
+	// Ctx sudah tidak valid saat job jalan di background
+	locale := sharedValidation.Locale(c)
+	fn := func(ctx context.Context, progress jobs.Progress) (any, error) {
+		return s.run(ctx, me.Id, locale, sheet, req.DryRun, progress)
+	}
+
+	if len(sheet.Rows) > importSyncRows {
+		return jobs.Start(c.Context(), s.Redis, importJobKind, me.Id, fn)
+	}
+	return jobs.Run(c.Context(), s.Redis, importJobKind, me.Id, fn)
+}
+
+func (s importService) GetJob(c *fiber.Ctx, id string) (*jobs.Job, error) {
+	me, ok := c.Locals("user").(*model.User)
+	if !ok || me == nil {
+		return nil, apperror.ErrUnauthenticated
+	}
+
+	job, err := jobs.Get(c.Context(), s.Redis, id, me.Id)
+	if errors.Is(err, jobs.ErrNotFound) || (err == nil && job.Kind != importJobKind) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to get import job: %+v", err)
+		return nil, err
+	}
+	return job, nil
+}
+
+// ReportURL returns a signed URL of the CSV report (every row with its outcome).
+func (s importService) ReportURL(c *fiber.Ctx, id string) (string, error) {
+	job, err := s.GetJob(c, id)
+	if err != nil {
+		return "", err
+	}
+
+	var result ImportResult
+	if len(job.Result) > 0 {
+		_ = json.Unmarshal(job.Result, &result)
+	}
+	if result.ReportKey == "" {
+		return "", ErrReportNotFound
+	}
+	return s.Storage.SignedURL(c.Context(), result.ReportKey, config.StorageURLTTL)
+}
+
+func (s importService) run(ctx context.Context, ownerID uint, locale string, sheet *spreadsheet.Sheet, dryRun bool, progress jobs.Progress) (*ImportResult, error) {
+	rows := make([]ImportRowResult, len(sheet.Rows))
+	entities := make([]*model.User, 0, len(sheet.Rows))
+	rowOf := make([]int, 0, len(sheet.Rows)) // entity index -> row index
+	seen := map[string]int{}
+
+	for i, row := range sheet.Rows {
+		progress(i, len(sheet.Rows))
+
+		in := validation.ImportRow{
+			Name:      row.Values["name"],
+			Email:     row.Values["email"],
+			Password:  row.Values["password"],
+			Status:    strings.ToLower(row.Values["status"]),
+			AvatarURL: row.Values["avatar_url"],
+			Locale:    row.Values["locale"],
+			Timezone:  row.Values["timezone"],
+		}
+		email := validation.NormalizeEmail(in.Email)
+		rows[i] = ImportRowResult{Line: row.Line, Email: email}
+
+		if err := sharedValidation.StructCtx(ctx, s.Validate, in); err != nil {
+			if sharedValidation.CustomErrorMessages(err, locale) == nil {
+				// query validator gagal, bukan data yang salah
+				return nil, err
+			}
+			rows[i].fail(validationMessage(err, locale))
+			continue
+		}
+		if line, ok := seen[email]; ok {
+			rows[i].fail(fmt.Sprintf("Duplicate email, already on line %d", line))
+			continue
+		}
+		seen[email] = row.Line
+
+		user, err := importedUser(in, email, dryRun)
+		if err != nil {
+			rows[i].fail(err.Error())
+			continue
+		}
+		entities = append(entities, user)
+		rowOf = append(rowOf, i)
+	}
+
+	if dryRun {
+		existing, err := s.existingEmails(ctx, seen)
+		if err != nil {
+			return nil, err
+		}
+		for j, user := range entities {
+			rows[rowOf[j]].Status = baseRepo.RowInserted
+			if _, ok := existing[*user.Email]; ok {
+				rows[rowOf[j]].Status = baseRepo.RowUpdated
+			}
+		}
+	} else {
+		opts := baseRepo.BulkOptions{
+			BatchSize:       importBatchSize,
+			ConflictColumns: []clause.Column{{Name: "email"}},
+			ConflictWhere:   []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}},
+			OnConflict:      baseRepo.ConflictUpdate,
+			UpdateColumns:   importUpdateColumns(sheet.Columns),
+		}
+		bulk, err := s.Repository.BulkUpsert(ctx, entities, opts)
+		if err != nil {
+			return nil, err
+		}
+		// upsert bisa mengubah user mana saja
+		httpcache.Invalidate(ctx, "users")
+		for _, r := range bulk.Rows {
+			rows[rowOf[r.Index]].Status = r.Status
+			rows[rowOf[r.Index]].Error = r.Reason
+		}
+	}
+	progress(len(sheet.Rows), len(sheet.Rows))
+
+	result := &ImportResult{DryRun: dryRun, Total: len(rows), Errors: []ImportRowResult{}}
+	for _, row := range rows {
+		switch row.Status {
+		case baseRepo.RowInserted:
+			result.Inserted++
+		case baseRepo.RowUpdated:
+			result.Updated++
+		case baseRepo.RowFailed:
+			result.Failed++
+			if len(result.Errors) < importMaxErrors {
+				result.Errors = append(result.Errors, row)
+			}
+		}
+	}
+
+	if result.Failed > 0 {
+		key, err := s.writeReport(ctx, ownerID, rows)
+		if err != nil {
+			// import sudah jalan, report gagal bukan alasan untuk gagal total
+			s.Log.WithContext(ctx).Errorf("Failed to store import report: %+v", err)
+		}
+		result.ReportKey = key
+	}
+	return result, nil
+}
+
+func (s importService) existingEmails(ctx context.Context, emails map[string]int) (map[string]struct{}, error) {
+	list := make([]string, 0, len(emails))
+	for email := range emails {
+		list = append(list, email)
+	}
+
+	existing := make(map[string]struct{}, len(list))
+	for chunk := range slices.Chunk(list, importBatchSize) {
+		var found []string
+		if err := s.Repository.DB().WithContext(ctx).
+			Model(&model.User{}).
+			Where("email IN ?", chunk).
+			Pluck("email", &found).Error; err != nil {
+			return nil, err
+		}
+		for _, email := range found {
+			existing[email] = struct{}{}
+		}
+	}
+	return existing, nil
+}
+
+func (s importService) writeReport(ctx context.Context, ownerID uint, rows []ImportRowResult) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"line", "email", "status", "error"})
+	for _, row := range rows {
+		_ = w.Write([]string{strconv.Itoa(row.Line), row.Email, string(row.Status), row.Error})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+
+	key := storage.NewKey(fmt.Sprintf("reports/users-import/%d", ownerID), ".csv")
+	if _, err := s.Storage.Put(ctx, key, &buf, int64(buf.Len()), "text/csv"); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (r *ImportRowResult) fail(reason string) {
+	r.Status = baseRepo.RowFailed
+	r.Error = reason
+}
+
+func importedUser(in validation.ImportRow, email string, dryRun bool) (*model.User, error) {
+	user := &model.User{
+		Name:  in.Name,
+		Email: &email,
+		Plan:  config.DefaultPlan, // kolom plan tidak punya default, ikut config
+	}
+	if in.Status != "" {
+		user.Status = model.UserStatus(in.Status)
+	}
+	if in.Locale != "" {
+		user.Locale = in.Locale
+	}
+	if in.Timezone != "" {
+		user.Timezone = in.Timezone
+	}
+	if in.AvatarURL != "" {
+		user.AvatarURL = &in.AvatarURL
+	}
+
+	// hashing mahal, dry-run tidak perlu
+	if in.Password != "" && !dryRun {
+		hash, err := secure.Hash(in.Password, nil)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+	return user, nil
+}
+
+// importUpdateColumns keeps existing values of the columns missing from the file.
+func importUpdateColumns(columns []string) []string {
+	update := []string{"updated_at"}
+	for _, col := range importUpdatable {
+		if slices.Contains(columns, col) {
+			update = append(update, col)
+		}
+	}
+	return update
+}
+
+func validationMessage(err error, locale string) string {
+	messages := sharedValidation.CustomErrorMessages(err, locale)
+	if len(messages) == 0 {
+		return err.Error()
+	}
+
+	list := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		list = append(list, msg)
+	}
+	slices.Sort(list)
+	return strings.Join(list, "; ")
+}
+
+func readFormFile(c *fiber.Ctx, field string) ([]byte, error) {
+	fh, err := c.FormFile(field)
+	if err != nil {
+		return nil, ErrImportFileMissing.Msg("Field " + field + " must be a file")
+	}
+	if fh.Size > config.UploadMaxSize {
+		return nil, ErrImportTooLarge
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/mail"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/metering"
+	model "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/models"
+	repository "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/repositories"
+	validation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/validations"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/secure"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	emailChangePrefix = "users:email-change:"
+	avatarMaxSize     = 2 << 20
+)
+
+// MeService is the self-service counterpart of UserService, every method
+// acts on the authenticated user stored in c.Locals("user") by middleware.Auth.
+type MeService interface {
+	GetMe(ctx *fiber.Ctx) (*model.User, error)
+	UpdateMe(ctx *fiber.Ctx, req *validation.UpdateMe) (*model.User, error)
+	ChangePassword(ctx *fiber.Ctx, req *validation.ChangePassword) error
+	RequestEmailChange(ctx *fiber.Ctx, req *validation.ChangeEmail) error
+	ConfirmEmailChange(ctx *fiber.Ctx, token string) (*model.User, error)
+	DeleteMe(ctx *fiber.Ctx, req *validation.DeleteMe) (time.Time, error)
+	UploadAvatar(ctx *fiber.Ctx) (*model.User, error)
+	RemoveAvatar(ctx *fiber.Ctx) (*model.User, error)
+	Usage(ctx *fiber.Ctx) (*metering.Report, error)
+	AvatarURL(ctx *fiber.Ctx, id uint) (string, error)
+	PurgeDeleted(ctx context.Context) (int64, error)
+}
+
+type meService struct {
+	Log        *logrus.Logger
+	Validate   *validator.Validate
+	Repository repository.UserRepository
+	Redis      *redis.Client
+	Storage    storage.Driver
+}
+
+type emailChange struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func NewMeService(repo repository.UserRepository, rdb *redis.Client, driver storage.Driver, validate *validator.Validate) MeService {
+	return &meService{
+		Log:        utils.Log,
+		Validate:   validate,
+		Repository: repo,
+		Redis:      rdb,
+		Storage:    driver,
+	}
+}
+
+func (s meService) GetMe(c *fiber.Ctx) (*model.User, error) {
+	user, ok := c.Locals("user").(*model.User)
+	if !ok || user == nil {
+		return nil, apperror.ErrUnauthenticated
+	}
+	return user, nil
+}
+
+func (s meService) UpdateMe(c *fiber.Ctx, req *validation.UpdateMe) (*model.User, error) {
+	me, err := s.GetMe(c)
+	if err != nil {
+		return nil, err
+	}
+
+	updateBody := make(map[string]any)
+
+	if req.Name != nil {
+		updateBody["name"] = *req.Name
+	}
+	if req.AvatarURL.Set {
+		// null atau string kosong = hapus avatar
+		if req.AvatarURL.V == "" {
+			updateBody["avatar_url"] = nil
+		} else {
+			updateBody["avatar_url"] = req.AvatarURL
+		}
+		updateBody["avatar_key"] = nil
+	}
+	if req.Locale.Set {
+		updateBody["locale"] = orDefault(req.Locale)
+	}
+	if req.Timezone.Set {
+		updateBody["timezone"] = orDefault(req.Timezone)
+	}
+	if len(updateBody) == 0 {
+		return me, nil
+	}
+
+	if err := s.Repository.PatchOne(c.Context(), me.Id, updateBody, nil); err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to update current user: %+v", err)
+		return nil, err
+	}
+	invalidateCache(c.Context(), me.Id)
+	if req.AvatarURL.Set {
+		s.deleteAvatar(c.Context(), me.AvatarKey)
+	}
+
+	return s.Repository.GetByID(c.Context(), me.Id, nil)
+}
+
+func (s meService) ChangePassword(c *fiber.Ctx, req *validation.ChangePassword) error {
+	me, err := s.verifyPassword(c, req.CurrentPassword)
+	if err != nil {
+		return err
+	}
+
+	hash, err := secure.Hash(req.NewPassword, nil)
+	if err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to hash password: %+v", err)
+		return err
+	}
+
+	// token yang sudah terbit (termasuk yang mungkin bocor) tidak berlaku lagi,
+	// detik dibulatkan ke bawah seperti iat JWT
+	if err := s.Repository.PatchOne(c.Context(), me.Id, map[string]any{
+		"password_hash":      hash,
+		"tokens_valid_after": time.Now().Truncate(time.Second),
+	}, nil); err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to change password: %+v", err)
+		return err
+	}
+	return nil
+}
+
+// RequestEmailChange keeps the current email until the new address is
+// confirmed through the link sent to it.
+func (s meService) RequestEmailChange(c *fiber.Ctx, req *validation.ChangeEmail) error {
+	me, err := s.verifyPassword(c, req.Password)
+	if err != nil {
+		return err
+	}
+
+	email := validation.NormalizeEmail(req.Email)
+	if me.Email != nil && email == *me.Email {
+		return ErrSameEmail
+	}
+	if err := s.ensureEmailAvailable(c.Context(), email); err != nil {
+		return err
+	}
+
+	token, err := secure.RandomToken(32)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(emailChange{UserID: me.Id, Email: email})
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(config.JWTVerifyEmailExp) * time.Minute
+	if err := s.Redis.Set(c.Context(), emailChangePrefix+secure.SHA256Hex(token), payload, ttl).Err(); err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to store email change: %+v", err)
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/users/email/verify?token=%s", config.AppURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your new email address by opening the link below, it expires in %d minutes:\n\n%s\n\nIgnore this email if you did not request the change.",
+		me.Name, config.JWTVerifyEmailExp, link)
+
+	if err := mail.Send(email, "Confirm your new email address", body); err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to send verification email: %+v", err)
+		return ErrMailFailed.Wrap(err)
+	}
+	return nil
+}
+
+func (s meService) ConfirmEmailChange(c *fiber.Ctx, token string) (*model.User, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	// GetDel: token hanya bisa dipakai sekali
+	raw, err := s.Redis.GetDel(c.Context(), emailChangePrefix+secure.SHA256Hex(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to load email change: %+v", err)
+		return nil, err
+	}
+
+	var change emailChange
+	if err := json.Unmarshal(raw, &change); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = s.Repository.PatchOne(c.Context(), change.UserID, map[string]any{
+		"email":             change.Email,
+		"email_verified_at": time.Now(),
+	}, nil)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrEmailTaken
+	}
+	if err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to change email: %+v", err)
+		return nil, err
+	}
+	invalidateCache(c.Context(), change.UserID)
+
+	return s.Repository.GetByID(c.Context(), change.UserID, nil)
+}
+
+// DeleteMe soft deletes the account and schedules its purge once
+// config.DeletionGrace has passed (see PurgeDeleted).
+func (s meService) DeleteMe(c *fiber.Ctx, req *validation.DeleteMe) (time.Time, error) {
+	me, err := s.verifyPassword(c, req.Password)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// soft delete + purge_at dalam satu update, delete oleh admin tidak di-purge
+	now := time.Now()
+	purgeAt := now.Add(config.DeletionGrace)
+	if err := s.Repository.PatchOne(c.Context(), me.Id, map[string]any{
+		"deleted_at": now,
+		"purge_at":   purgeAt,
+	}, nil); err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to delete current user: %+v", err)
+		return time.Time{}, err
+	}
+	invalidateCache(c.Context(), me.Id)
+	return purgeAt, nil
+}
+
+// ---- AVATAR ----
+
+// UploadAvatar stores the "avatar" multipart image and points avatar_url to
+// the stable /users/:id/avatar redirect, the version query busts client caches.
+func (s meService) UploadAvatar(c *fiber.Ctx) (*model.User, error) {
+	me, err := s.GetMe(c)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := storage.Upload(c.Context(), c, s.Storage, fmt.Sprintf("avatars/%d", me.Id), storage.UploadOptions{
+		Field:        "avatar",
+		MaxSize:      avatarMaxSize,
+		AllowedTypes: storage.ImageTypes,
+	})
+	if err != nil {
+		var fiberErr *fiber.Error
+		if !errors.As(err, &fiberErr) {
+			s.Log.WithContext(c.Context()).Errorf("Failed to upload avatar: %+v", err)
+		}
+		return nil, err
+	}
+
+	avatarURL := fmt.Sprintf("%s/api/users/%d/avatar?v=%d", config.AppURL, me.Id, obj.ModifiedAt.Unix())
+	if err := s.Repository.PatchOne(c.Context(), me.Id, map[string]any{
+		"avatar_key": obj.Key,
+		"avatar_url": avatarURL,
+	}, nil); err != nil {
+		s.deleteAvatar(c.Context(), &obj.Key)
+		s.Log.WithContext(c.Context()).Errorf("Failed to update avatar: %+v", err)
+		return nil, err
+	}
+	invalidateCache(c.Context(), me.Id)
+	s.deleteAvatar(c.Context(), me.AvatarKey)
+
+	return s.Repository.GetByID(c.Context(), me.Id, nil)
+}
+
+func (s meService) RemoveAvatar(c *fiber.Ctx) (*model.User, error) {
+	me, err := s.GetMe(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repository.PatchOne(c.Context(), me.Id, map[string]any{
+		"avatar_key": nil,
+		"avatar_url": nil,
+	}, nil); err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to remove avatar: %+v", err)
+		return nil, err
+	}
+	invalidateCache(c.Context(), me.Id)
+	s.deleteAvatar(c.Context(), me.AvatarKey)
+
+	return s.Repository.GetByID(c.Context(), me.Id, nil)
+}
+
+// Usage reports the quotas of the current user's plan for this month.
+func (s meService) Usage(c *fiber.Ctx) (*metering.Report, error) {
+	me, err := s.GetMe(c)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := metering.Usage(c.Context(), me)
+	if err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to get usage: %+v", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// AvatarURL returns a fresh signed URL of an uploaded avatar.
+func (s meService) AvatarURL(c *fiber.Ctx, id uint) (string, error) {
+	user, err := s.Repository.GetByID(c.Context(), id, nil)
+	if err != nil || user.AvatarKey == nil {
+		return "", ErrAvatarNotFound
+	}
+	return s.Storage.SignedURL(c.Context(), *user.AvatarKey, config.StorageURLTTL)
+}
+
+func (s meService) deleteAvatar(ctx context.Context, key *string) {
+	deleteAvatar(ctx, s.Log, s.Storage, key)
+}
+
+// deleteAvatar is best effort, an orphan object is only wasted space.
+func deleteAvatar(ctx context.Context, log *logrus.Logger, driver storage.Driver, key *string) {
+	if driver == nil || key == nil || *key == "" {
+		return
+	}
+	if err := driver.Delete(ctx, *key); err != nil {
+		log.WithContext(ctx).Warnf("Failed to delete avatar %s: %+v", *key, err)
+	}
+}
+
+// PurgeDeleted hard deletes the accounts deleted by their own user (DeleteMe)
+// whose grace period is over, with their avatars. Users deleted by an admin
+// are kept.
+func (s meService) PurgeDeleted(ctx context.Context) (int64, error) {
+	// deleted users are no longer cached, no need to go through the cache layer
+	var purged []model.User
+	result := s.Repository.DB().WithContext(ctx).
+		Unscoped().
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "avatar_key"}}}).
+		Where("deleted_at IS NOT NULL AND purge_at IS NOT NULL AND purge_at < ?", time.Now()).
+		Delete(&purged)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	// setelah row terhapus, avatar yatim hanya memakan tempat
+	for _, user := range purged {
+		s.deleteAvatar(ctx, user.AvatarKey)
+	}
+	return result.RowsAffected, nil
+}
+
+// PurgeLoop runs PurgeDeleted every interval until ctx is done, it is started
+// once by main through UserModule.RunBackground.
+func PurgeLoop(ctx context.Context, s MeService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.PurgeDeleted(ctx)
+			if err != nil {
+				utils.Log.Errorf("Failed to purge deleted users: %+v", err)
+			} else if n > 0 {
+				utils.Log.Infof("Purged %d deleted users", n)
+			}
+		}
+	}
+}
+
+// verifyPassword reloads the current user with its password hash, which
+// is never cached, and checks plain against it.
+func (s meService) verifyPassword(c *fiber.Ctx, plain string) (*model.User, error) {
+	me, err := s.GetMe(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// modifier non-nil = bypass cache
+	user, err := s.Repository.GetByID(c.Context(), me.Id, func(db *gorm.DB) *gorm.DB { return db })
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash == "" || !secure.Verify(user.PasswordHash, plain) {
+		return nil, ErrIncorrectPassword
+	}
+	return user, nil
+}
+
+func (s meService) ensureEmailAvailable(ctx context.Context, email string) error {
+	_, total, err := s.Repository.GetAll(ctx, 0, 1, func(db *gorm.DB) *gorm.DB {
+		return db.Where("email = ?", email)
+	})
+	if err != nil {
+		return err
+	}
+	if total > 0 {
+		return ErrEmailTaken
+	}
+	return nil
+}
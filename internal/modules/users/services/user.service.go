@@ -1,12 +1,21 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"iter"
+	"time"
 
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/httpcache"
 	model "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/models"
 	repository "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/repositories"
 	validation "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users/validations"
+	baseRepo "github.com/hafizhproject45/Golang-Boilerplate.git/internal/repository"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/storage"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/secure"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -16,6 +25,8 @@ import (
 
 type UserService interface {
 	GetAll(ctx *fiber.Ctx, params *validation.Query) ([]model.User, int64, error)
+	Export(ctx *fiber.Ctx, params *validation.Query) (iter.Seq2[model.User, error], error)
+	Freshness(ctx *fiber.Ctx, params *validation.Query) (time.Time, int64, error)
 	GetOne(ctx *fiber.Ctx, id uint) (*model.User, error)
 	CreateOne(ctx *fiber.Ctx, req *validation.Create) (*model.User, error)
 	UpdateOne(ctx *fiber.Ctx, req *validation.Update, id uint) (*model.User, error)
@@ -26,94 +37,193 @@ type userService struct {
 	Log        *logrus.Logger
 	Validate   *validator.Validate
 	Repository repository.UserRepository
+	Storage    storage.Driver // uploaded avatars replaced by UpdateOne are deleted, may be nil
 }
 
-func NewUserService(repo repository.UserRepository, validate *validator.Validate) UserService {
+func NewUserService(repo repository.UserRepository, driver storage.Driver, validate *validator.Validate) UserService {
 	return &userService{
 		Log:        utils.Log,
 		Validate:   validate,
 		Repository: repo,
+		Storage:    driver,
 	}
 }
 func (s userService) GetAll(c *fiber.Ctx, params *validation.Query) ([]model.User, int64, error) {
-	if err := s.Validate.Struct(params); err != nil {
-		return nil, 0, err
-	}
-
 	offset := (params.Page - 1) * params.Limit
 
 	users, total, err := s.Repository.GetAll(c.Context(), offset, params.Limit, func(db *gorm.DB) *gorm.DB {
 		if params.Search != "" {
-			return db.Where("name LIKE ?", "%"+params.Search+"%")
+			opts := searchOptions(params)
+			opts.Highlight = true
+			return baseRepo.Search(opts)(db)
 		}
 		return db.Order("created_at DESC").Order("updated_at DESC")
 	})
 
 	if err != nil {
-		s.Log.Errorf("Failed to get users: %+v", err)
+		s.Log.WithContext(c.Context()).Errorf("Failed to get users: %+v", err)
 		return nil, 0, err
 	}
 	return users, total, nil
 }
 
+// Export streams every user matching the GetAll filters, ordered by id.
+func (s userService) Export(c *fiber.Ctx, params *validation.Query) (iter.Seq2[model.User, error], error) {
+	return s.Repository.Stream(c.Context(), 0, func(db *gorm.DB) *gorm.DB {
+		opts := searchOptions(params)
+		opts.Unranked = true
+		return baseRepo.Search(opts)(db)
+	}), nil
+}
+
+// Freshness returns the last updated_at and count behind the GetAll list,
+// its ETag / Last-Modified.
+func (s userService) Freshness(c *fiber.Ctx, params *validation.Query) (time.Time, int64, error) {
+	lastModified, total, err := s.Repository.Freshness(c.Context(), func(db *gorm.DB) *gorm.DB {
+		opts := searchOptions(params)
+		opts.Unranked = true
+		return baseRepo.Search(opts)(db)
+	})
+	if err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to get users freshness: %+v", err)
+		return time.Time{}, 0, err
+	}
+	return lastModified, total, nil
+}
+
+func searchOptions(params *validation.Query) baseRepo.SearchOptions {
+	return baseRepo.SearchOptions{
+		Term:    params.Search,
+		Columns: []string{"name", "email"},
+		Vector:  "search_vector",
+		Prefix:  true,
+		Fuzzy:   true,
+	}
+}
+
 func (s userService) GetOne(c *fiber.Ctx, id uint) (*model.User, error) {
 	user, err := s.Repository.GetByID(c.Context(), id, nil)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
-		s.Log.Errorf("Failed get user by id: %+v", err)
+		s.Log.WithContext(c.Context()).Errorf("Failed get user by id: %+v", err)
 		return nil, err
 	}
 	return user, nil
 }
 
 func (s *userService) CreateOne(c *fiber.Ctx, req *validation.Create) (*model.User, error) {
-	if err := s.Validate.Struct(req); err != nil {
+	hash, err := secure.Hash(req.Password, nil)
+	if err != nil {
+		s.Log.WithContext(c.Context()).Errorf("Failed to hash password: %+v", err)
 		return nil, err
 	}
 
 	createBody := &model.User{
-		Name: req.Name,
+		Name:         req.Name,
+		Email:        &req.Email,
+		PasswordHash: hash,
+		Status:       model.UserStatus(req.Status),
+		AvatarURL:    req.AvatarURL,
+		Locale:       req.Locale,
+		Timezone:     req.Timezone,
+		Plan:         config.DefaultPlan,
 	}
 
 	if err := s.Repository.CreateOne(c.Context(), createBody, nil); err != nil {
-		s.Log.Errorf("Failed to create user: %+v", err)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrEmailTaken
+		}
+		s.Log.WithContext(c.Context()).Errorf("Failed to create user: %+v", err)
 		return nil, err
 	}
+	invalidateCache(c.Context(), createBody.Id)
 
 	return createBody, nil
 }
 
 func (s userService) UpdateOne(c *fiber.Ctx, req *validation.Update, id uint) (*model.User, error) {
-	if err := s.Validate.Struct(req); err != nil {
-		return nil, err
-	}
-
 	updateBody := make(map[string]any)
 
 	if req.Name != nil {
 		updateBody["name"] = *req.Name
 	}
+	if req.Email != nil {
+		updateBody["email"] = *req.Email
+	}
+	if req.Password != nil {
+		hash, err := secure.Hash(*req.Password, nil)
+		if err != nil {
+			s.Log.WithContext(c.Context()).Errorf("Failed to hash password: %+v", err)
+			return nil, err
+		}
+		updateBody["password_hash"] = hash
+	}
+	if req.Status != nil {
+		updateBody["status"] = *req.Status
+	}
+	var oldAvatarKey *string
+	if req.AvatarURL.Set {
+		// null atau string kosong = hapus avatar
+		if req.AvatarURL.V == "" {
+			updateBody["avatar_url"] = nil
+		} else {
+			updateBody["avatar_url"] = req.AvatarURL
+		}
+		updateBody["avatar_key"] = nil
+
+		// object avatar upload dihapus setelah update, jangan jadi orphan
+		current, err := s.GetOne(c, id)
+		if err != nil {
+			return nil, err
+		}
+		oldAvatarKey = current.AvatarKey
+	}
+	if req.Locale.Set {
+		updateBody["locale"] = orDefault(req.Locale)
+	}
+	if req.Timezone.Set {
+		updateBody["timezone"] = orDefault(req.Timezone)
+	}
 
 	if err := s.Repository.PatchOne(c.Context(), id, updateBody, nil); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+			return nil, ErrUserNotFound
 		}
-		s.Log.Errorf("Failed to update user: %+v", err)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrEmailTaken
+		}
+		s.Log.WithContext(c.Context()).Errorf("Failed to update user: %+v", err)
 		return nil, err
 	}
+	invalidateCache(c.Context(), id)
+	deleteAvatar(c.Context(), s.Log, s.Storage, oldAvatarKey)
 
 	return s.GetOne(c, id)
 }
 
+// orDefault resets a NOT NULL column to its default on null or "".
+func orDefault(n utils.Nullable[string]) any {
+	if n.V == "" {
+		return gorm.Expr("DEFAULT")
+	}
+	return n.V
+}
+
 func (s userService) DeleteOne(c *fiber.Ctx, id uint) error {
 	if err := s.Repository.DeleteOne(c.Context(), id); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fiber.NewError(fiber.StatusNotFound, "User not found")
+			return ErrUserNotFound
 		}
-		s.Log.Errorf("Failed to delete user: %+v", err)
+		s.Log.WithContext(c.Context()).Errorf("Failed to delete user: %+v", err)
 		return err
 	}
+	invalidateCache(c.Context(), id)
 	return nil
 }
+
+// invalidateCache drops the cached GET /users responses showing the user.
+func invalidateCache(ctx context.Context, id uint) {
+	httpcache.Invalidate(ctx, "users:list", fmt.Sprintf("users:%d", id))
+}
@@ -1,15 +1,101 @@
 package validation
 
+import (
+	"strings"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+)
+
+func init() {
+	utils.RegisterNullable[string]()
+}
+
 type Create struct {
-	Name   string `json:"name" validate:"required_strict,min=3"`
+	Name      string  `json:"name" validate:"required_strict,min=3,max=50"`
+	Email     string  `json:"email" validate:"required_strict,email,max=255,unique=users.email"`
+	Password  string  `json:"password" validate:"required_strict,password"`
+	Status    string  `json:"status" validate:"omitempty,oneof=pending active suspended"`
+	AvatarURL *string `json:"avatar_url,omitempty" validate:"omitempty,http_url,max=2048"`
+	Locale    string  `json:"locale" validate:"omitempty,bcp47_language_tag,max=35"`
+	Timezone  string  `json:"timezone" validate:"omitempty,timezone,max=64"`
+}
+
+// NormalizeEmail matches the chk_users_email_lower constraint.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ByID is the :id path param of the single user routes.
+type ByID struct {
+	ID uint `param:"id" validate:"required"`
+}
+
+// Normalize runs before unique=users.email compares the address as is.
+func (r *Create) Normalize() {
+	r.Email = NormalizeEmail(r.Email)
 }
 
 type Update struct {
-	Name   *string `json:"name,omitempty" validate:"omitempty,max=50"`
+	Name      *string                `json:"name,omitempty" validate:"omitempty,min=3,max=50"`
+	Email     *string                `json:"email,omitempty" validate:"omitempty,email,max=255,unique_except_self=users.email"`
+	Password  *string                `json:"password,omitempty" validate:"omitempty,password"`
+	Status    *string                `json:"status,omitempty" validate:"omitempty,oneof=pending active suspended"`
+	AvatarURL utils.Nullable[string] `json:"avatar_url" validate:"omitempty,http_url,max=2048"`     // null = remove
+	Locale    utils.Nullable[string] `json:"locale" validate:"omitempty,bcp47_language_tag,max=35"` // null = back to the default
+	Timezone  utils.Nullable[string] `json:"timezone" validate:"omitempty,timezone,max=64"`         // null = back to the default
+}
+
+func (r *Update) Normalize() {
+	if r.Email != nil {
+		email := NormalizeEmail(*r.Email)
+		r.Email = &email
+	}
+}
+
+// ---- current user ("me") ----
+
+type UpdateMe struct {
+	Name      *string                `json:"name,omitempty" validate:"omitempty,min=3,max=50"`
+	AvatarURL utils.Nullable[string] `json:"avatar_url" validate:"omitempty,http_url,max=2048"`     // null = remove
+	Locale    utils.Nullable[string] `json:"locale" validate:"omitempty,bcp47_language_tag,max=35"` // null = back to the default
+	Timezone  utils.Nullable[string] `json:"timezone" validate:"omitempty,timezone,max=64"`         // null = back to the default
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required_strict"`
+	NewPassword     string `json:"new_password" validate:"required_strict,password,nefield=CurrentPassword"`
+}
+
+type ChangeEmail struct {
+	Email    string `json:"email" validate:"required_strict,email,max=255"`
+	Password string `json:"password" validate:"required_strict"`
+}
+
+type DeleteMe struct {
+	Password string `json:"password" validate:"required_strict"`
+}
+
+// ---- import ----
+
+type Import struct {
+	DryRun  bool   `query:"dry_run"`
+	Mapping string `form:"mapping" validate:"omitempty,json"` // {"E-mail Address": "email", ...}
+}
+
+// ImportRow is one spreadsheet row, passwords are optional (users without
+// one stay unable to log in until they reset it).
+type ImportRow struct {
+	Name      string `json:"name" validate:"required_strict,min=3,max=50"`
+	Email     string `json:"email" validate:"required_strict,email,max=255"`
+	Password  string `json:"password" validate:"omitempty,password"`
+	Status    string `json:"status" validate:"omitempty,oneof=pending active suspended"`
+	AvatarURL string `json:"avatar_url" validate:"omitempty,http_url,max=2048"`
+	Locale    string `json:"locale" validate:"omitempty,bcp47_language_tag,max=35"`
+	Timezone  string `json:"timezone" validate:"omitempty,timezone,max=64"`
 }
 
 type Query struct {
-	Page   int    `query:"page" validate:"omitempty,number,min=1"`
-	Limit  int    `query:"limit" validate:"omitempty,number,min=1,max=100"`
+	Page   int    `query:"page" default:"1" validate:"omitempty,number,min=1"`
+	Limit  int    `query:"limit" default:"10" validate:"omitempty,number,min=1,max=100"`
 	Search string `query:"search" validate:"omitempty,max=50"`
 }
@@ -0,0 +1,173 @@
+package patch
+
+import (
+	"encoding/json"
+	"maps"
+	"mime"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/validation"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	MergePatch = "application/merge-patch+json" // RFC 7396
+	JSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+// AcceptPatch is the Accept-Patch header value of PATCH routes using Bind.
+var AcceptPatch = strings.Join([]string{fiber.MIMEApplicationJSON, MergePatch, JSONPatch}, ", ")
+
+var (
+	ErrInvalidDocument = apperror.Define(apperror.BadRequest, "patch_invalid_document", "Invalid patch document")
+	ErrNotApplicable   = apperror.Define(apperror.Conflict, "patch_not_applicable", "Patch could not be applied to the current resource")
+	ErrRejected        = apperror.Define(apperror.Unprocessable, "patch_rejected", "Patch changes fields that cannot be changed")
+	ErrUnsupportedType = apperror.Define(apperror.UnsupportedMedia, "patch_unsupported_type", "Use application/json, application/merge-patch+json or application/json-patch+json")
+)
+
+// nullable is implemented by utils.Nullable, the only fields that accept null.
+type nullable interface{ ValidationValue() any }
+
+// Bind fills dst, a struct of pointer / utils.Nullable fields, from a PATCH body.
+//
+// application/json is decoded as is. Merge and JSON Patch documents are
+// applied to current (the resource as the client reads it, ex: its detail
+// DTO), then the fields that changed are decoded into dst. dst is then
+// normalized and validated like validation.Bind does, ex: after
+// validation.Self for unique_except_self.
+func Bind(c *fiber.Ctx, dst any, current func() (any, error)) error {
+	c.Set("Accept-Patch", AcceptPatch)
+
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	switch mediaType {
+	case "", fiber.MIMEApplicationJSON:
+		if err := c.BodyParser(dst); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+		return validateBody(c, dst)
+	case MergePatch, JSONPatch:
+	default:
+		return ErrUnsupportedType
+	}
+
+	doc, err := current()
+	if err != nil {
+		return err
+	}
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	if mediaType == MergePatch {
+		if !json.Valid(c.Body()) {
+			return ErrInvalidDocument
+		}
+		patched, err = jsonpatch.MergePatch(original, c.Body())
+		if err != nil {
+			return ErrInvalidDocument.Wrap(err)
+		}
+	} else {
+		ops, err := jsonpatch.DecodePatch(c.Body())
+		if err != nil {
+			return ErrInvalidDocument.Wrap(err)
+		}
+		patched, err = ops.Apply(original)
+		if err != nil {
+			// test gagal / path tidak ada
+			return ErrNotApplicable.Msg(ErrNotApplicable.Message + ": " + err.Error())
+		}
+	}
+
+	changes, err := diff(original, patched)
+	if err != nil {
+		return ErrInvalidDocument.Wrap(err)
+	}
+	if err := check(changes, reflect.TypeOf(dst)); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return ErrInvalidDocument.Wrap(err)
+	}
+	return validateBody(c, dst)
+}
+
+// validateBody checks the patched fields against the tags of dst.
+func validateBody(c *fiber.Ctx, dst any) error {
+	if n, ok := dst.(validation.Normalizer); ok {
+		n.Normalize()
+	}
+
+	validate, err := validation.Validator()
+	if err != nil {
+		return err
+	}
+	return validation.StructCtx(c.Context(), validate, dst)
+}
+
+// diff returns the top level members that differ, removed ones as null.
+func diff(original, patched []byte) (map[string]any, error) {
+	var before, after map[string]any
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return nil, err
+	}
+
+	changes := map[string]any{}
+	for key, value := range after {
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			changes[key] = value
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changes[key] = nil
+		}
+	}
+	return changes, nil
+}
+
+// check rejects changes dst has no field for and nulls on non-nullable fields.
+func check(changes map[string]any, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f.Type
+	}
+
+	errorsMap := map[string]string{}
+	for _, key := range slices.Sorted(maps.Keys(changes)) {
+		ft, ok := fields[key]
+		switch {
+		case !ok:
+			errorsMap[key] = "Field " + key + " cannot be changed"
+		case changes[key] == nil && !ft.Implements(reflect.TypeFor[nullable]()):
+			errorsMap[key] = "Field " + key + " cannot be null"
+		}
+	}
+	if len(errorsMap) > 0 {
+		return ErrRejected.With("errors", errorsMap)
+	}
+	return nil
+}
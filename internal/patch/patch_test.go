@@ -0,0 +1,208 @@
+package patch
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type update struct {
+	Name     *string                `json:"name,omitempty"`
+	Nickname utils.Nullable[string] `json:"nickname"`
+	Tags     *[]string              `json:"tags,omitempty"`
+}
+
+// detail is what the client reads, id is not part of update.
+type detail struct {
+	Id       uint     `json:"id"`
+	Name     string   `json:"name"`
+	Nickname *string  `json:"nickname"`
+	Tags     []string `json:"tags"`
+}
+
+func bind(t *testing.T, contentType, body string) (*update, error) {
+	t.Helper()
+
+	nickname := "ann"
+	current := detail{Id: 1, Name: "Ann", Nickname: &nickname, Tags: []string{"a", "b"}}
+
+	var (
+		dst     update
+		bindErr error
+	)
+	app := fiber.New()
+	app.Patch("/", func(c *fiber.Ctx) error {
+		bindErr = Bind(c, &dst, func() (any, error) { return current, nil })
+		return nil
+	})
+
+	req := httptest.NewRequest(fiber.MethodPatch, "/", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if got := resp.Header.Get("Accept-Patch"); got != AcceptPatch {
+		t.Errorf("Accept-Patch = %q, want %q", got, AcceptPatch)
+	}
+	return &dst, bindErr
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		check func(*testing.T, *update)
+		err   error
+	}{
+		{
+			name: "changed member",
+			body: `{"name":"Bob"}`,
+			check: func(t *testing.T, u *update) {
+				if u.Name == nil || *u.Name != "Bob" {
+					t.Errorf("name = %v, want Bob", u.Name)
+				}
+				if u.Nickname.Set {
+					t.Errorf("nickname set, want absent")
+				}
+			},
+		},
+		{
+			name: "unchanged member is not sent",
+			body: `{"name":"Ann","nickname":"ann"}`,
+			check: func(t *testing.T, u *update) {
+				if u.Name != nil || u.Nickname.Set {
+					t.Errorf("got changes %+v, want none", u)
+				}
+			},
+		},
+		{
+			name: "null clears a nullable field",
+			body: `{"nickname":null}`,
+			check: func(t *testing.T, u *update) {
+				if !u.Nickname.Set || u.Nickname.Valid {
+					t.Errorf("nickname = %+v, want set to null", u.Nickname)
+				}
+			},
+		},
+		{
+			name: "arrays are replaced",
+			body: `{"tags":["c"]}`,
+			check: func(t *testing.T, u *update) {
+				if u.Tags == nil || len(*u.Tags) != 1 || (*u.Tags)[0] != "c" {
+					t.Errorf("tags = %v, want [c]", u.Tags)
+				}
+			},
+		},
+		{name: "null on a non-nullable field", body: `{"name":null}`, err: ErrRejected},
+		{name: "read-only member", body: `{"id":2}`, err: ErrRejected},
+		{name: "unknown member", body: `{"role":"admin"}`, err: ErrRejected},
+		{name: "invalid JSON", body: `{"name":`, err: ErrInvalidDocument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := bind(t, MergePatch, tt.body)
+			assertErr(t, err, tt.err)
+			if tt.check != nil && err == nil {
+				tt.check(t, u)
+			}
+		})
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		check func(*testing.T, *update)
+		err   error
+	}{
+		{
+			name: "test then replace",
+			body: `[{"op":"test","path":"/name","value":"Ann"},{"op":"replace","path":"/name","value":"Bob"}]`,
+			check: func(t *testing.T, u *update) {
+				if u.Name == nil || *u.Name != "Bob" {
+					t.Errorf("name = %v, want Bob", u.Name)
+				}
+			},
+		},
+		{
+			name: "remove a nullable member",
+			body: `[{"op":"remove","path":"/nickname"}]`,
+			check: func(t *testing.T, u *update) {
+				if !u.Nickname.Set || u.Nickname.Valid {
+					t.Errorf("nickname = %+v, want set to null", u.Nickname)
+				}
+			},
+		},
+		{
+			name: "add to an array",
+			body: `[{"op":"add","path":"/tags/-","value":"c"}]`,
+			check: func(t *testing.T, u *update) {
+				if u.Tags == nil || strings.Join(*u.Tags, ",") != "a,b,c" {
+					t.Errorf("tags = %v, want [a b c]", u.Tags)
+				}
+			},
+		},
+		{name: "failed test", body: `[{"op":"test","path":"/name","value":"Bob"}]`, err: ErrNotApplicable},
+		{name: "missing path", body: `[{"op":"replace","path":"/missing/x","value":1}]`, err: ErrNotApplicable},
+		{name: "remove a non-nullable member", body: `[{"op":"remove","path":"/name"}]`, err: ErrRejected},
+		{name: "read-only member", body: `[{"op":"replace","path":"/id","value":2}]`, err: ErrRejected},
+		{name: "not an operation list", body: `{"op":"replace"}`, err: ErrInvalidDocument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := bind(t, JSONPatch, tt.body)
+			assertErr(t, err, tt.err)
+			if tt.check != nil && err == nil {
+				tt.check(t, u)
+			}
+		})
+	}
+}
+
+func TestPlainJSON(t *testing.T) {
+	u, err := bind(t, fiber.MIMEApplicationJSON, `{"name":"Ann","nickname":null}`)
+	if err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	// decoded as is, not compared with the current resource
+	if u.Name == nil || *u.Name != "Ann" || !u.Nickname.Set || u.Nickname.Valid {
+		t.Errorf("got %+v", u)
+	}
+}
+
+func TestUnsupportedType(t *testing.T) {
+	_, err := bind(t, "text/plain", `name=Bob`)
+	assertErr(t, err, ErrUnsupportedType)
+}
+
+func TestRejectedFields(t *testing.T) {
+	_, err := bind(t, MergePatch, `{"id":2,"name":null}`)
+	appErr, ok := apperror.As(err)
+	if !ok {
+		t.Fatalf("err = %v, want *apperror.Error", err)
+	}
+	fields, _ := appErr.Meta["errors"].(map[string]string)
+	if fields["id"] == "" || fields["name"] == "" {
+		t.Errorf("errors = %v, want id and name", fields)
+	}
+}
+
+func assertErr(t *testing.T, err, want error) {
+	t.Helper()
+	switch {
+	case want == nil && err != nil:
+		t.Fatalf("Bind: %v", err)
+	case want != nil && !errors.Is(err, want):
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// headers of draft-ietf-httpapi-ratelimit-headers
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+	HeaderPolicy    = "RateLimit-Policy"
+)
+
+// Headers are the response headers set by the limiter, for CORS ExposeHeaders.
+var Headers = []string{HeaderLimit, HeaderRemaining, HeaderReset, HeaderPolicy, fiber.HeaderRetryAfter}
+
+var (
+	ErrRateLimited = apperror.Define(apperror.RateLimited, "rate_limited", "Too many requests, please try again later")
+	ErrUnavailable = apperror.Define(apperror.Unavailable, "rate_limit_unavailable", "Too many requests cannot be checked right now, please try again later")
+)
+
+const keyPrefix = "ratelimit:"
+
+type Algorithm int
+
+const (
+	// SlidingWindow counts requests of the current and previous window,
+	// weighted by how much of the previous one still overlaps.
+	SlidingWindow Algorithm = iota
+	// TokenBucket refills Limit tokens per Window, so bursts up to Limit are
+	// allowed after a quiet period.
+	TokenBucket
+)
+
+type Policy struct {
+	Name           string                    // part of the Redis key, routes with the same name share counters
+	Limit          int                       // requests (or tokens) per Window
+	Window         time.Duration             // default 1 minute
+	Algorithm      Algorithm                 // default SlidingWindow
+	Key            func(c *fiber.Ctx) string // who is limited, default ByIP
+	SkipSuccessful bool                      // give the request back when the response is < 400, ex: login attempts
+	FailOpen       bool                      // let requests through when Redis fails, otherwise they get 503
+}
+
+// client used by New, set once at startup with UseRedis
+var (
+	rdbMu sync.RWMutex
+	rdb   *redis.Client
+)
+
+func UseRedis(client *redis.Client) {
+	rdbMu.Lock()
+	defer rdbMu.Unlock()
+	rdb = client
+}
+
+func redisClient() *redis.Client {
+	rdbMu.RLock()
+	defer rdbMu.RUnlock()
+	return rdb
+}
+
+// ByIP limits each client address.
+func ByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// ByUser limits c.Locals("user"), which implements RateLimitKey, so it goes
+// after middleware.Auth. Anonymous requests fall back to ByIP.
+//
+// There is no key per API key: the app has no API key authentication yet,
+// and an unverified key header changes on every request. A verified key
+// should be keyed like ByUser, from what its authenticator put in Locals.
+func ByUser(c *fiber.Ctx) string {
+	if user, ok := c.Locals("user").(interface{ RateLimitKey() string }); ok {
+		return "user:" + user.RateLimitKey()
+	}
+	return ByIP(c)
+}
+
+// New limits the requests of a route, counters live in Redis so every
+// process (Prefork) and instance shares them:
+//
+//	route.Post("/import", middleware.Auth(s), ratelimit.New(ratelimit.Policy{
+//		Name:  "users-import",
+//		Limit: 5,
+//		Key:   ratelimit.ByUser,
+//	}), importCtrl.Import)
+//
+// Responses carry RateLimit-* headers, a rejected request gets 429 with
+// Retry-After. Keys come from the server side (address, authenticated user),
+// never from a client header a caller could change on every request. When a
+// Redis call fails the request gets 503 unless the policy is FailOpen, without
+// UseRedis the limiter is off.
+func New(policy Policy) fiber.Handler {
+	if policy.Window <= 0 {
+		policy.Window = time.Minute
+	}
+	if policy.Key == nil {
+		policy.Key = ByIP
+	}
+	policyHeader := strconv.Itoa(policy.Limit) + ";w=" + strconv.Itoa(int(math.Ceil(policy.Window.Seconds())))
+
+	return func(c *fiber.Ctx) error {
+		client := redisClient()
+		if client == nil || policy.Limit <= 0 {
+			return c.Next()
+		}
+
+		key := keyPrefix + policy.Name + ":" + policy.Key(c)
+		// SkipSuccessful juga dihitung di awal, request paralel tidak lolos bersamaan
+		res, err := take(c, client, policy, key)
+		if err != nil {
+			utils.Log.WithContext(c.Context()).Warnf("Rate limit %s failed: %v", policy.Name, err)
+			if !policy.FailOpen {
+				return ErrUnavailable
+			}
+			return c.Next()
+		}
+
+		c.Set(HeaderPolicy, policyHeader)
+		c.Set(HeaderLimit, strconv.Itoa(policy.Limit))
+		if !res.allowed {
+			retryAfter := seconds(res.reset)
+			c.Set(HeaderRemaining, "0")
+			c.Set(HeaderReset, strconv.Itoa(retryAfter))
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return ErrRateLimited.With("retry_after", retryAfter)
+		}
+		c.Set(HeaderRemaining, strconv.Itoa(res.remaining))
+		c.Set(HeaderReset, strconv.Itoa(seconds(res.reset)))
+
+		if !policy.SkipSuccessful {
+			return c.Next()
+		}
+
+		err = c.Next()
+		status := c.Response().StatusCode()
+		if err != nil {
+			// error belum diubah jadi response oleh ErrorHandler
+			status = utils.ErrorStatus(err)
+		}
+		if status < fiber.StatusBadRequest {
+			if res, err := giveBack(c, client, policy, key, res); err == nil {
+				c.Set(HeaderRemaining, strconv.Itoa(res.remaining))
+			} else {
+				utils.Log.WithContext(c.Context()).Warnf("Rate limit %s give back failed: %v", policy.Name, err)
+			}
+		}
+		return err
+	}
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
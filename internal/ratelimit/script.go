@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Both scripts read the clock of Redis (TIME), so app servers with a skewed
+// clock still share one window. ARGV: limit, window in ms, take (1 consumes
+// a request, -1 gives one back), window index the request was counted in
+// (sliding window, when giving back). They return {allowed, remaining, ms,
+// idx}, ms is the time until the limit resets, or until the next request is
+// allowed.
+
+var slidingWindow = redis.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local limit, window, take = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+
+local idx = math.floor(now / window)
+local elapsed = now - idx * window
+
+if take == -1 then
+	-- window asal bisa sudah lewat, kembalikan ke window itu
+	local from = tonumber(ARGV[4])
+	if tonumber(redis.call('HGET', KEYS[1], from) or '0') > 0 then
+		redis.call('HINCRBY', KEYS[1], from, -1)
+	end
+	local curr = tonumber(redis.call('HGET', KEYS[1], idx) or '0')
+	local prev = tonumber(redis.call('HGET', KEYS[1], idx - 1) or '0')
+	return {1, math.floor(limit - prev * (window - elapsed) / window - curr), window - elapsed, from}
+end
+
+local curr = tonumber(redis.call('HGET', KEYS[1], idx) or '0')
+local prev = tonumber(redis.call('HGET', KEYS[1], idx - 1) or '0')
+local count = prev * (window - elapsed) / window + curr
+
+if count + 1 > limit then
+	local wait = window - elapsed
+	if curr + 1 <= limit then
+		-- prev masih terlalu berat, tunggu sampai bobotnya cukup turun
+		wait = wait - (limit - curr - 1) * window / prev
+	elseif curr > 0 then
+		-- window ini penuh, di window berikutnya curr menjadi prev
+		wait = wait + window * (1 - (limit - 1) / curr)
+	end
+	return {0, 0, math.ceil(wait), idx}
+end
+
+redis.call('HINCRBY', KEYS[1], idx, 1)
+redis.call('HDEL', KEYS[1], idx - 2)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {1, math.floor(limit - count - 1), window - elapsed, idx}
+`)
+
+var tokenBucket = redis.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local limit, window, take = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local rate = limit / window
+
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or limit
+local ts = tonumber(data[2]) or now
+tokens = math.min(limit, tokens + (now - ts) * rate)
+
+if take == -1 then
+	tokens = math.min(limit, tokens + 1)
+elseif tokens < 1 then
+	return {0, 0, math.ceil((1 - tokens) / rate), 0}
+else
+	tokens = tokens - 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {1, math.floor(tokens), math.ceil((limit - tokens) / rate), 0}
+`)
+
+type result struct {
+	allowed   bool
+	remaining int
+	reset     time.Duration // until the limit resets, or until a rejected request may retry
+	window    int64         // sliding window the request was counted in, for giveBack
+}
+
+// take consumes a request of key.
+func take(c *fiber.Ctx, client *redis.Client, policy Policy, key string) (result, error) {
+	return run(c, client, policy, key, 1, 0)
+}
+
+// giveBack returns the request consumed by take, ex: SkipSuccessful.
+func giveBack(c *fiber.Ctx, client *redis.Client, policy Policy, key string, taken result) (result, error) {
+	return run(c, client, policy, key, -1, taken.window)
+}
+
+func run(c *fiber.Ctx, client *redis.Client, policy Policy, key string, n int, window int64) (result, error) {
+	script := slidingWindow
+	if policy.Algorithm == TokenBucket {
+		script = tokenBucket
+	}
+
+	values, err := script.Run(c.Context(), client, []string{key}, policy.Limit, policy.Window.Milliseconds(), n, window).Int64Slice()
+	if err != nil {
+		return result{}, err
+	}
+	return result{
+		allowed:   values[0] == 1,
+		remaining: int(max(values[1], 0)),
+		reset:     time.Duration(values[2]) * time.Millisecond,
+		window:    values[3],
+	}, nil
+}
@@ -0,0 +1,473 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type ConflictAction int
+
+const (
+	ConflictError     ConflictAction = iota // plain INSERT, conflicting rows fail
+	ConflictDoNothing                       // ON CONFLICT DO NOTHING, conflicting rows are skipped
+	ConflictUpdate                          // ON CONFLICT DO UPDATE, conflicting rows are updated
+)
+
+type BulkOptions struct {
+	BatchSize       int                 // default DefaultBatchSize
+	ConflictColumns []clause.Column     // required by ConflictDoNothing / ConflictUpdate
+	ConflictWhere   []clause.Expression // predicate of a partial unique index, ex: deleted_at IS NULL
+	OnConflict      ConflictAction
+	UpdateColumns   []string // columns written on conflict (BulkInsert) or update (BulkUpdate), empty = all
+	UseCopy         bool     // Postgres COPY fast path, only with ConflictError; ids are not returned, ignored inside WithTx
+}
+
+type RowStatus string
+
+const (
+	RowInserted RowStatus = "inserted"
+	RowUpdated  RowStatus = "updated"
+	RowSkipped  RowStatus = "skipped"
+	RowFailed   RowStatus = "failed"
+)
+
+type RowResult struct {
+	Index  int       `json:"index"`
+	Status RowStatus `json:"status"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+type BulkResult struct {
+	Inserted int         `json:"inserted"`
+	Updated  int         `json:"updated"`
+	Skipped  int         `json:"skipped"`
+	Failed   int         `json:"failed"`
+	Rows     []RowResult `json:"rows"`
+}
+
+func (b *BulkResult) add(index int, status RowStatus, err error) {
+	row := RowResult{Index: index, Status: status}
+	switch status {
+	case RowInserted:
+		b.Inserted++
+	case RowUpdated:
+		b.Updated++
+	case RowSkipped:
+		b.Skipped++
+	case RowFailed:
+		b.Failed++
+		if err != nil {
+			row.Reason = err.Error()
+		}
+	}
+	b.Rows = append(b.Rows, row)
+}
+
+// ---- BULK INSERT / UPSERT ----
+
+// BulkInsert writes entities batch by batch. A failing batch is retried row by
+// row (each inside its own savepoint) so one bad row does not sink the others.
+// The returned error is only set when the database itself is unusable.
+func (r *BaseRepositoryImpl[T, ID]) BulkInsert(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error) {
+	if opts.OnConflict != ConflictError && len(opts.ConflictColumns) == 0 {
+		return nil, errors.New("bulk insert: ConflictColumns is required with an ON CONFLICT policy")
+	}
+	if opts.UseCopy && opts.OnConflict != ConflictError {
+		return nil, errors.New("bulk insert: COPY cannot handle conflicts")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	result := &BulkResult{Rows: make([]RowResult, 0, len(entities))}
+	for start := 0; start < len(entities); start += opts.BatchSize {
+		end := min(start+opts.BatchSize, len(entities))
+
+		var err error
+		if opts.UseCopy {
+			err = r.copyBatch(ctx, entities[start:end], start, result)
+		} else {
+			err = r.insertBatch(ctx, entities[start:end], start, opts, result)
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// BulkUpsert is BulkInsert with ConflictUpdate.
+func (r *BaseRepositoryImpl[T, ID]) BulkUpsert(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error) {
+	opts.OnConflict = ConflictUpdate
+	opts.UseCopy = false
+	return r.BulkInsert(ctx, entities, opts)
+}
+
+func (r *BaseRepositoryImpl[T, ID]) insertBatch(ctx context.Context, batch []*T, offset int, opts BulkOptions, result *BulkResult) error {
+	return r.writer(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := r.existingKeys(tx, batch, opts)
+		if err != nil {
+			return err
+		}
+		// status of a written row, its key exists for the rows after it
+		status := func(entity *T) RowStatus {
+			if opts.OnConflict == ConflictError {
+				return RowInserted
+			}
+			key := r.conflictKey(tx, entity, opts.ConflictColumns)
+			if _, ok := existing[key]; !ok {
+				existing[key] = struct{}{}
+				return RowInserted
+			}
+			if opts.OnConflict == ConflictDoNothing {
+				return RowSkipped
+			}
+			return RowUpdated
+		}
+
+		statuses := make([]RowStatus, len(batch))
+		errs := make([]error, len(batch))
+		rowByRow := func(indices []int) {
+			for _, i := range indices {
+				rowErr := tx.Transaction(func(sp *gorm.DB) error {
+					return onConflict(sp, opts).Create(batch[i]).Error
+				})
+				if rowErr != nil {
+					statuses[i], errs[i] = RowFailed, rowErr
+					continue
+				}
+				statuses[i] = status(batch[i])
+			}
+		}
+
+		first, repeated := r.splitRepeated(tx, batch, opts)
+		unique := make([]*T, len(first))
+		for j, i := range first {
+			unique[j] = batch[i]
+		}
+		batchErr := tx.Transaction(func(sp *gorm.DB) error {
+			return onConflict(sp, opts).Create(&unique).Error
+		})
+		if batchErr == nil {
+			for _, i := range first {
+				statuses[i] = status(batch[i])
+			}
+			rowByRow(repeated)
+		} else {
+			all := make([]int, len(batch))
+			for i := range all {
+				all[i] = i
+			}
+			rowByRow(all)
+		}
+
+		for i := range batch {
+			result.add(offset+i, statuses[i], errs[i])
+		}
+		return nil
+	})
+}
+
+// splitRepeated splits the batch into the first row of every conflict key and
+// the rows repeating a key, which are written one by one after the others:
+// ON CONFLICT cannot touch the same row twice in one statement.
+func (r *BaseRepositoryImpl[T, ID]) splitRepeated(db *gorm.DB, batch []*T, opts BulkOptions) (first, repeated []int) {
+	seen := make(map[string]struct{}, len(batch))
+	for i, entity := range batch {
+		if opts.OnConflict != ConflictError {
+			key := r.conflictKey(db, entity, opts.ConflictColumns)
+			if _, ok := seen[key]; ok {
+				repeated = append(repeated, i)
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+		first = append(first, i)
+	}
+	return first, repeated
+}
+
+func onConflict(db *gorm.DB, opts BulkOptions) *gorm.DB {
+	target := clause.OnConflict{Columns: opts.ConflictColumns}
+	if len(opts.ConflictWhere) > 0 {
+		target.TargetWhere = clause.Where{Exprs: opts.ConflictWhere}
+	}
+
+	switch opts.OnConflict {
+	case ConflictDoNothing:
+		target.DoNothing = true
+	case ConflictUpdate:
+		if len(opts.UpdateColumns) > 0 {
+			target.DoUpdates = clause.AssignmentColumns(opts.UpdateColumns)
+		} else {
+			target.UpdateAll = true
+		}
+	default:
+		return db
+	}
+	return db.Clauses(target)
+}
+
+// existingKeys loads the conflict keys of the batch that are already stored,
+// used to tell inserted rows from updated / skipped ones.
+func (r *BaseRepositoryImpl[T, ID]) existingKeys(tx *gorm.DB, batch []*T, opts BulkOptions) (map[string]struct{}, error) {
+	existing := map[string]struct{}{}
+	if opts.OnConflict == ConflictError {
+		return existing, nil
+	}
+
+	names := make([]string, len(opts.ConflictColumns))
+	quoted := make([]string, len(opts.ConflictColumns))
+	for i, col := range opts.ConflictColumns {
+		names[i] = col.Name
+		quoted[i] = tx.Statement.Quote(col)
+	}
+
+	keys := make([]any, 0, len(batch))
+	for _, entity := range batch {
+		values := r.conflictValues(tx, entity, opts.ConflictColumns)
+		if len(values) == 1 {
+			keys = append(keys, values[0])
+		} else {
+			keys = append(keys, values)
+		}
+	}
+
+	// scan ke T supaya tipe kolom sama dengan nilai entity
+	var rows []*T
+	if err := tx.Model(new(T)).
+		Select(names).
+		Where(fmt.Sprintf("(%s) IN ?", strings.Join(quoted, ", ")), keys).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		existing[r.conflictKey(tx, row, opts.ConflictColumns)] = struct{}{}
+	}
+	return existing, nil
+}
+
+func (r *BaseRepositoryImpl[T, ID]) conflictValues(db *gorm.DB, entity *T, columns []clause.Column) []any {
+	sch, err := parseSchema(db, entity)
+	if err != nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(entity).Elem()
+	values := make([]any, len(columns))
+	for i, col := range columns {
+		if field := sch.LookUpField(col.Name); field != nil {
+			values[i], _ = field.ValueOf(db.Statement.Context, rv)
+		}
+	}
+	return values
+}
+
+func (r *BaseRepositoryImpl[T, ID]) conflictKey(db *gorm.DB, entity *T, columns []clause.Column) string {
+	values := r.conflictValues(db, entity, columns)
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = keyPart(v)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// keyPart formats a conflict column value, Valuer types (uuid, Nullable, ...)
+// by their driver value and times in UTC so equal values give equal keys.
+func keyPart(v any) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			v = value
+		}
+	}
+	switch value := v.(type) {
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(value)
+	}
+	return fmt.Sprint(v)
+}
+
+// ---- COPY ----
+
+// copyBatch streams the batch with COPY FROM. COPY is all or nothing, so a
+// failing batch falls back to the regular insert path to isolate bad rows.
+// Zero values get their column default like Create: literal defaults are
+// written, columns with an expression default are left out of the COPY.
+func (r *BaseRepositoryImpl[T, ID]) copyBatch(ctx context.Context, batch []*T, offset int, result *BulkResult) error {
+	// COPY butuh koneksi pgx sendiri, di dalam WithTx pakai insert biasa
+	// supaya tetap ikut transaksi
+	if _, inTx := r.db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return r.insertBatch(ctx, batch, offset, BulkOptions{}, result)
+	}
+
+	sch, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*schema.Field, 0, len(sch.Fields))
+	for _, field := range sch.Fields {
+		if field.DBName == "" || !field.Creatable || field.AutoIncrement ||
+			(field.PrimaryKey && field.HasDefaultValue && field.DefaultValueInterface == nil) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+
+	now := time.Now()
+	rows := make([][]any, len(batch))
+	var omit []bool // per field: zero with an expression default, left to the database
+	for i, entity := range batch {
+		// COPY melewati callback gorm, jalankan hook & timestamp manual
+		if hook, ok := any(entity).(callbacks.BeforeCreateInterface); ok {
+			if err := hook.BeforeCreate(r.db); err != nil {
+				return r.insertBatch(ctx, batch, offset, BulkOptions{}, result)
+			}
+		}
+
+		rv := reflect.ValueOf(entity).Elem()
+		row := make([]any, len(fields))
+		useDefault := make([]bool, len(fields))
+		for j, field := range fields {
+			value, zero := field.ValueOf(ctx, rv)
+			switch {
+			case zero && (field.AutoCreateTime > 0 || field.AutoUpdateTime > 0):
+				_ = field.Set(ctx, rv, now)
+				value, _ = field.ValueOf(ctx, rv)
+			case zero && field.DefaultValueInterface != nil:
+				_ = field.Set(ctx, rv, field.DefaultValueInterface)
+				value, _ = field.ValueOf(ctx, rv)
+			case zero && field.HasDefaultValue:
+				useDefault[j] = true
+			}
+			row[j] = value
+		}
+		if i == 0 {
+			omit = useDefault
+		} else if !slices.Equal(omit, useDefault) {
+			// kolom yang memakai default berbeda antar baris, tidak bisa satu COPY
+			return r.insertBatch(ctx, batch, offset, BulkOptions{}, result)
+		}
+		rows[i] = row
+	}
+
+	columns := make([]string, 0, len(fields))
+	for j, field := range fields {
+		if !omit[j] {
+			columns = append(columns, field.DBName)
+		}
+	}
+	for i, row := range rows {
+		kept := row[:0]
+		for j, value := range row {
+			if !omit[j] {
+				kept = append(kept, value)
+			}
+		}
+		rows[i] = kept
+	}
+
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	copyErr := conn.Raw(func(driverConn any) error {
+		pgConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("bulk insert: COPY requires the pgx driver")
+		}
+		_, err := pgConn.Conn().CopyFrom(ctx, pgx.Identifier{sch.Table}, columns, pgx.CopyFromRows(rows))
+		return err
+	})
+	if copyErr != nil {
+		if errors.Is(copyErr, sql.ErrConnDone) || ctx.Err() != nil {
+			return copyErr
+		}
+		return r.insertBatch(ctx, batch, offset, BulkOptions{}, result)
+	}
+
+	database.MarkWrite(ctx)
+	for i := range batch {
+		result.add(offset+i, RowInserted, nil)
+	}
+	return nil
+}
+
+// ---- BULK UPDATE ----
+
+// BulkUpdate updates every entity by primary key. Only opts.UpdateColumns are
+// written when given, rows that do not exist are reported as skipped.
+func (r *BaseRepositoryImpl[T, ID]) BulkUpdate(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	result := &BulkResult{Rows: make([]RowResult, 0, len(entities))}
+	for start := 0; start < len(entities); start += opts.BatchSize {
+		end := min(start+opts.BatchSize, len(entities))
+
+		err := r.writer(ctx).Transaction(func(tx *gorm.DB) error {
+			for i, entity := range entities[start:end] {
+				var affected int64
+				rowErr := tx.Transaction(func(sp *gorm.DB) error {
+					q := sp.Model(entity)
+					if len(opts.UpdateColumns) > 0 {
+						q = q.Select(opts.UpdateColumns)
+					} else {
+						q = q.Select("*").Omit("id", "created_at")
+					}
+					res := q.Updates(entity)
+					affected = res.RowsAffected
+					return res.Error
+				})
+
+				switch {
+				case rowErr != nil:
+					result.add(start+i, RowFailed, rowErr)
+				case affected == 0:
+					result.add(start+i, RowSkipped, nil)
+				default:
+					result.add(start+i, RowUpdated, nil)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func parseSchema(db *gorm.DB, model any) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+)
+
+// Codec serializes cached entities. JSONCodec is used when none is given.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// keyRoot namespaces repository entries, Flush never reaches keys of other
+// features that start with the same prefix (ex: "users:email-change:").
+const keyRoot = "repo:"
+
+type CacheOptions struct {
+	Prefix string        // key prefix under keyRoot, ex: "users"
+	TTL    time.Duration // default 5 minutes
+	Codec  Codec         // default JSONCodec
+}
+
+// CachedRepositoryImpl is a cache-aside decorator for BaseRepository.
+// Only GetByID / GetByIDs without modifier are served from Redis,
+// every write that can touch a cached row invalidates it.
+type CachedRepositoryImpl[T any, ID utils.ID] struct {
+	BaseRepository[T, ID]
+
+	rdb    *redis.Client
+	opts   CacheOptions
+	group  *singleflight.Group
+	bypass bool
+	// WithTx di dalam Transaction: invalidasi diulang setelah commit
+	afterCommit *[]func()
+}
+
+func NewCachedRepository[T any, ID utils.ID](base BaseRepository[T, ID], rdb *redis.Client, opts CacheOptions) *CachedRepositoryImpl[T, ID] {
+	if opts.Prefix == "" {
+		opts.Prefix = base.DB().NamingStrategy.TableName(reflect.TypeOf(new(T)).Elem().Name())
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 5 * time.Minute
+	}
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec{}
+	}
+
+	return &CachedRepositoryImpl[T, ID]{
+		BaseRepository: base,
+		rdb:            rdb,
+		opts:           opts,
+		group:          &singleflight.Group{},
+	}
+}
+
+// fromPrimary reads cache fills from the primary, a lagging replica would
+// keep a stale row cached for the whole TTL.
+func fromPrimary(q *gorm.DB) *gorm.DB {
+	return q.Clauses(dbresolver.Write)
+}
+
+// ---- READ ----
+func (r *CachedRepositoryImpl[T, ID]) GetByID(
+	ctx context.Context,
+	id ID,
+	modifier func(*gorm.DB) *gorm.DB,
+) (*T, error) {
+	if modifier != nil || r.bypass {
+		return r.BaseRepository.GetByID(ctx, id, modifier)
+	}
+
+	key := r.key(id)
+	if entity, ok := r.get(ctx, key); ok {
+		return entity, nil
+	}
+
+	v, err, _ := r.group.Do(key, func() (any, error) {
+		// shared by every waiting caller, so it must outlive the first one
+		ctx := context.WithoutCancel(ctx)
+		entity, err := r.BaseRepository.GetByID(ctx, id, fromPrimary)
+		if err != nil {
+			return nil, err
+		}
+		r.set(ctx, key, entity)
+		return entity, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// singleflight shares the pointer between callers
+	entity := *v.(*T)
+	return &entity, nil
+}
+
+func (r *CachedRepositoryImpl[T, ID]) GetByIDs(
+	ctx context.Context,
+	ids []ID,
+	modifier func(*gorm.DB) *gorm.DB,
+) ([]T, error) {
+	if modifier != nil || r.bypass || len(ids) == 0 {
+		return r.BaseRepository.GetByIDs(ctx, ids, modifier)
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = r.key(id)
+	}
+
+	values, err := r.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		utils.Log.WithContext(ctx).Warnf("Cache mget %s failed: %v", r.opts.Prefix, err)
+		values = make([]any, len(keys))
+	}
+
+	entities := make([]T, 0, len(ids))
+	misses := make([]ID, 0, len(ids))
+	for i, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			misses = append(misses, ids[i])
+			continue
+		}
+		entity := new(T)
+		if err := r.opts.Codec.Unmarshal([]byte(raw), entity); err != nil {
+			misses = append(misses, ids[i])
+			continue
+		}
+		entities = append(entities, *entity)
+	}
+	if len(misses) == 0 {
+		return entities, nil
+	}
+
+	fetched, err := r.BaseRepository.GetByIDs(ctx, misses, fromPrimary)
+	if err != nil && !(errors.Is(err, gorm.ErrRecordNotFound) && len(entities) > 0) {
+		return nil, err
+	}
+
+	pipe := r.rdb.Pipeline()
+	for i := range fetched {
+		id, ok := primaryKey(r.DB(), &fetched[i])
+		if !ok {
+			continue
+		}
+		if data, err := r.opts.Codec.Marshal(&fetched[i]); err == nil {
+			pipe.Set(ctx, r.key(id), data, r.opts.TTL)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.Log.WithContext(ctx).Warnf("Cache fill %s failed: %v", r.opts.Prefix, err)
+	}
+
+	return append(entities, fetched...), nil
+}
+
+// ---- WRITE ----
+func (r *CachedRepositoryImpl[T, ID]) UpdateOne(
+	ctx context.Context,
+	id ID,
+	entity *T,
+	modifier func(*gorm.DB) *gorm.DB,
+) error {
+	defer r.invalidate(ctx, r.key(id))
+	return r.BaseRepository.UpdateOne(ctx, id, entity, modifier)
+}
+
+func (r *CachedRepositoryImpl[T, ID]) UpdateMany(
+	ctx context.Context,
+	entities []*T,
+	modifier func(*gorm.DB) *gorm.DB,
+) error {
+	keys := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		if id, ok := primaryKey(r.DB(), entity); ok {
+			keys = append(keys, r.key(id))
+		}
+	}
+	defer r.invalidate(ctx, keys...)
+	return r.BaseRepository.UpdateMany(ctx, entities, modifier)
+}
+
+// BulkInsert only touches existing rows when they are updated on conflict.
+func (r *CachedRepositoryImpl[T, ID]) BulkInsert(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error) {
+	if opts.OnConflict == ConflictUpdate {
+		defer r.Flush(ctx)
+	}
+	return r.BaseRepository.BulkInsert(ctx, entities, opts)
+}
+
+func (r *CachedRepositoryImpl[T, ID]) BulkUpsert(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error) {
+	defer r.Flush(ctx)
+	return r.BaseRepository.BulkUpsert(ctx, entities, opts)
+}
+
+func (r *CachedRepositoryImpl[T, ID]) BulkUpdate(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error) {
+	keys := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		if id, ok := primaryKey(r.DB(), entity); ok {
+			keys = append(keys, r.key(id))
+		}
+	}
+	defer r.invalidate(ctx, keys...)
+	return r.BaseRepository.BulkUpdate(ctx, entities, opts)
+}
+
+func (r *CachedRepositoryImpl[T, ID]) PatchOne(
+	ctx context.Context,
+	id ID,
+	updates map[string]any,
+	modifier func(*gorm.DB) *gorm.DB,
+) error {
+	defer r.invalidate(ctx, r.key(id))
+	return r.BaseRepository.PatchOne(ctx, id, updates, modifier)
+}
+
+func (r *CachedRepositoryImpl[T, ID]) DeleteOne(ctx context.Context, id ID) error {
+	defer r.invalidate(ctx, r.key(id))
+	return r.BaseRepository.DeleteOne(ctx, id)
+}
+
+// DeleteMany cannot know which rows were removed, so the whole prefix is flushed.
+func (r *CachedRepositoryImpl[T, ID]) DeleteMany(ctx context.Context, modifier func(*gorm.DB) *gorm.DB) error {
+	defer r.Flush(ctx)
+	return r.BaseRepository.DeleteMany(ctx, modifier)
+}
+
+func (r *CachedRepositoryImpl[T, ID]) Upsert(
+	ctx context.Context,
+	entity *T,
+	conflictColumns []clause.Column,
+	modifier func(*gorm.DB) *gorm.DB,
+) error {
+	err := r.BaseRepository.Upsert(ctx, entity, conflictColumns, modifier)
+	if id, ok := primaryKey(r.DB(), entity); ok {
+		r.invalidate(ctx, r.key(id))
+	}
+	return err
+}
+
+// WithTx never reads from cache (uncommitted data must not leak), but writes
+// inside the transaction still invalidate. A read of another request can
+// refill a key with the row before the commit, use Transaction so the keys
+// are invalidated again once it is committed.
+func (r *CachedRepositoryImpl[T, ID]) WithTx(tx *gorm.DB) BaseRepository[T, ID] {
+	afterCommit, _ := tx.Get(afterCommitKey)
+	hooks, _ := afterCommit.(*[]func())
+	return &CachedRepositoryImpl[T, ID]{
+		BaseRepository: r.BaseRepository.WithTx(tx),
+		rdb:            r.rdb,
+		opts:           r.opts,
+		group:          r.group,
+		bypass:         true,
+		afterCommit:    hooks,
+	}
+}
+
+const afterCommitKey = "repository:after_commit"
+
+// Transaction is db.Transaction for cached repositories, their WithTx(tx)
+// invalidations run again after the commit:
+//
+//	err := repository.Transaction(ctx, db, func(tx *gorm.DB) error {
+//		return s.Repository.WithTx(tx).PatchOne(ctx, id, updates, nil)
+//	})
+func Transaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var hooks []func()
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(tx.Set(afterCommitKey, &hooks))
+	})
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// Flush removes every cached entry under the repository prefix.
+func (r *CachedRepositoryImpl[T, ID]) Flush(ctx context.Context) {
+	iter := r.rdb.Scan(ctx, 0, keyRoot+r.opts.Prefix+":*", 500).Iterator()
+	keys := make([]string, 0, 500)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == cap(keys) {
+			r.invalidate(ctx, keys...)
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		utils.Log.WithContext(ctx).Warnf("Cache scan %s failed: %v", r.opts.Prefix, err)
+	}
+	r.invalidate(ctx, keys...)
+}
+
+// ---- HELPERS ----
+func (r *CachedRepositoryImpl[T, ID]) key(id any) string {
+	return fmt.Sprintf("%s%s:%v", keyRoot, r.opts.Prefix, id)
+}
+
+func (r *CachedRepositoryImpl[T, ID]) get(ctx context.Context, key string) (*T, bool) {
+	data, err := r.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			utils.Log.WithContext(ctx).Warnf("Cache get %s failed: %v", key, err)
+		}
+		return nil, false
+	}
+
+	entity := new(T)
+	if err := r.opts.Codec.Unmarshal(data, entity); err != nil {
+		utils.Log.WithContext(ctx).Warnf("Cache decode %s failed: %v", key, err)
+		return nil, false
+	}
+	return entity, true
+}
+
+func (r *CachedRepositoryImpl[T, ID]) set(ctx context.Context, key string, entity *T) {
+	data, err := r.opts.Codec.Marshal(entity)
+	if err != nil {
+		utils.Log.WithContext(ctx).Warnf("Cache encode %s failed: %v", key, err)
+		return
+	}
+	if err := r.rdb.Set(ctx, key, data, r.opts.TTL).Err(); err != nil {
+		utils.Log.WithContext(ctx).Warnf("Cache set %s failed: %v", key, err)
+	}
+}
+
+func (r *CachedRepositoryImpl[T, ID]) invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	r.del(ctx, keys)
+	if r.afterCommit != nil {
+		keys := slices.Clone(keys)
+		*r.afterCommit = append(*r.afterCommit, func() { r.del(ctx, keys) })
+	}
+}
+
+func (r *CachedRepositoryImpl[T, ID]) del(ctx context.Context, keys []string) {
+	if err := r.rdb.Del(context.WithoutCancel(ctx), keys...).Err(); err != nil {
+		utils.Log.WithContext(ctx).Warnf("Cache invalidate %s failed: %v", r.opts.Prefix, err)
+	}
+}
+
+// primaryKey reads the primary key value of entity using the gorm schema.
+func primaryKey[T any](db *gorm.DB, entity *T) (any, bool) {
+	sch, err := parseSchema(db, entity)
+	if err != nil || sch.PrioritizedPrimaryField == nil {
+		return nil, false
+	}
+
+	value, zero := sch.PrioritizedPrimaryField.ValueOf(context.Background(), reflect.ValueOf(entity).Elem())
+	if zero {
+		return nil, false
+	}
+	return value, true
+}
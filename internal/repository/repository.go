@@ -2,41 +2,58 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"iter"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/database"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
-type BaseRepository[T any] interface {
+type BaseRepository[T any, ID utils.ID] interface {
 	GetAll(ctx context.Context, offset, limit int, modifier func(*gorm.DB) *gorm.DB) ([]T, int64, error)
-	GetByID(ctx context.Context, id uint, modifier func(*gorm.DB) *gorm.DB) (*T, error)
-	GetByIDs(ctx context.Context, ids []uint, modifier func(*gorm.DB) *gorm.DB) ([]T, error)
+	GetByID(ctx context.Context, id ID, modifier func(*gorm.DB) *gorm.DB) (*T, error)
+	GetByIDs(ctx context.Context, ids []ID, modifier func(*gorm.DB) *gorm.DB) ([]T, error)
+	FindInBatches(ctx context.Context, batchSize int, modifier func(*gorm.DB) *gorm.DB, fn func(batch []T) error) error
+	Stream(ctx context.Context, batchSize int, modifier func(*gorm.DB) *gorm.DB) iter.Seq2[T, error]
+	Freshness(ctx context.Context, modifier func(*gorm.DB) *gorm.DB) (time.Time, int64, error)
 
 	CreateOne(ctx context.Context, entity *T, modifier func(*gorm.DB) *gorm.DB) error
 	CreateMany(ctx context.Context, entities []*T, modifier func(*gorm.DB) *gorm.DB) error
+	BulkInsert(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error)
+	BulkUpsert(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error)
 
-	UpdateOne(ctx context.Context, id uint, entity *T, modifier func(*gorm.DB) *gorm.DB) error
+	UpdateOne(ctx context.Context, id ID, entity *T, modifier func(*gorm.DB) *gorm.DB) error
 	UpdateMany(ctx context.Context, entities []*T, modifier func(*gorm.DB) *gorm.DB) error
-	PatchOne(ctx context.Context, id uint, updates map[string]any, modifier func(*gorm.DB) *gorm.DB) error
+	BulkUpdate(ctx context.Context, entities []*T, opts BulkOptions) (*BulkResult, error)
+	PatchOne(ctx context.Context, id ID, updates map[string]any, modifier func(*gorm.DB) *gorm.DB) error
 
-	DeleteOne(ctx context.Context, id uint) error
+	DeleteOne(ctx context.Context, id ID) error
 	DeleteMany(ctx context.Context, modifier func(*gorm.DB) *gorm.DB) error
 
 	Upsert(ctx context.Context, entity *T, conflictColumns []clause.Column, modifier func(*gorm.DB) *gorm.DB) error
 
-	WithTx(tx *gorm.DB) BaseRepository[T]
+	WithTx(tx *gorm.DB) BaseRepository[T, ID]
 	DB() *gorm.DB
 }
 
-type BaseRepositoryImpl[T any] struct {
+// DefaultBatchSize is used by FindInBatches / Stream when batchSize <= 0.
+const DefaultBatchSize = 500
+
+var errStopStream = errors.New("stream stopped")
+
+type BaseRepositoryImpl[T any, ID utils.ID] struct {
 	db *gorm.DB
 }
 
-func NewBaseRepository[T any](db *gorm.DB) *BaseRepositoryImpl[T] {
-	return &BaseRepositoryImpl[T]{db: db}
+func NewBaseRepository[T any, ID utils.ID](db *gorm.DB) *BaseRepositoryImpl[T, ID] {
+	return &BaseRepositoryImpl[T, ID]{db: db}
 }
 
-func (r *BaseRepositoryImpl[T]) GetAll(
+func (r *BaseRepositoryImpl[T, ID]) GetAll(
 	ctx context.Context,
 	offset, limit int,
 	modifier func(*gorm.DB) *gorm.DB,
@@ -44,7 +61,7 @@ func (r *BaseRepositoryImpl[T]) GetAll(
 	var entities []T
 	var total int64
 
-	q := r.db.WithContext(ctx).Model(new(T))
+	q := database.Reader(ctx, r.db).Model(new(T))
 	if modifier != nil {
 		q = modifier(q)
 	}
@@ -59,29 +76,54 @@ func (r *BaseRepositoryImpl[T]) GetAll(
 	return entities, total, nil
 }
 
-func (r *BaseRepositoryImpl[T]) GetByID(
+// Freshness returns MAX(updated_at) and COUNT(*) of the matching rows, the
+// validators of a list (see httpcache.Weak). The modifier must not order.
+func (r *BaseRepositoryImpl[T, ID]) Freshness(
 	ctx context.Context,
-	id uint,
+	modifier func(*gorm.DB) *gorm.DB,
+) (time.Time, int64, error) {
+	var row struct {
+		LastModified *time.Time
+		Total        int64
+	}
+
+	q := database.Reader(ctx, r.db).Model(new(T))
+	if modifier != nil {
+		q = modifier(q)
+	}
+	if err := q.Select("MAX(updated_at) AS last_modified, COUNT(*) AS total").Scan(&row).Error; err != nil {
+		return time.Time{}, 0, err
+	}
+
+	if row.LastModified == nil {
+		return time.Time{}, row.Total, nil
+	}
+	return *row.LastModified, row.Total, nil
+}
+
+func (r *BaseRepositoryImpl[T, ID]) GetByID(
+	ctx context.Context,
+	id ID,
 	modifier func(*gorm.DB) *gorm.DB,
 ) (*T, error) {
 	entity := new(T)
-	q := r.db.WithContext(ctx)
+	q := database.Reader(ctx, r.db)
 	if modifier != nil {
 		q = modifier(q)
 	}
-	if err := q.First(entity, id).Error; err != nil {
+	if err := q.Where("id = ?", id).First(entity).Error; err != nil {
 		return nil, err
 	}
 	return entity, nil
 }
 
-func (r *BaseRepositoryImpl[T]) GetByIDs(
+func (r *BaseRepositoryImpl[T, ID]) GetByIDs(
 	ctx context.Context,
-	ids []uint,
+	ids []ID,
 	modifier func(*gorm.DB) *gorm.DB,
 ) ([]T, error) {
 	var entities []T
-	q := r.db.WithContext(ctx).Model(new(T))
+	q := database.Reader(ctx, r.db).Model(new(T))
 	if modifier != nil {
 		q = modifier(q)
 	}
@@ -96,25 +138,77 @@ func (r *BaseRepositoryImpl[T]) GetByIDs(
 	return entities, nil
 }
 
+// ---- BATCH READ ----
+
+// FindInBatches walks every matching row ordered by primary key (keyset
+// pagination), so the modifier must not add its own ORDER BY.
+// The batch slice is reused between calls, copy it if you keep it.
+func (r *BaseRepositoryImpl[T, ID]) FindInBatches(
+	ctx context.Context,
+	batchSize int,
+	modifier func(*gorm.DB) *gorm.DB,
+	fn func(batch []T) error,
+) error {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	var batch []T
+	q := database.Reader(ctx, r.db).Model(new(T))
+	if modifier != nil {
+		q = modifier(q)
+	}
+
+	return q.FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(batch)
+	}).Error
+}
+
+// Stream yields rows one by one, fetched batchSize at a time.
+// Breaking out of the range loop stops the query.
+func (r *BaseRepositoryImpl[T, ID]) Stream(
+	ctx context.Context,
+	batchSize int,
+	modifier func(*gorm.DB) *gorm.DB,
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := r.FindInBatches(ctx, batchSize, modifier, func(batch []T) error {
+			for _, entity := range batch {
+				if !yield(entity, nil) {
+					return errStopStream
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopStream) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
 // ---- CREATE ----
-func (r *BaseRepositoryImpl[T]) CreateOne(
+func (r *BaseRepositoryImpl[T, ID]) CreateOne(
 	ctx context.Context,
 	entity *T,
 	modifier func(*gorm.DB) *gorm.DB,
 ) error {
-	q := r.db.WithContext(ctx)
+	q := r.writer(ctx)
 	if modifier != nil {
 		q = modifier(q)
 	}
 	return q.Create(entity).Error
 }
 
-func (r *BaseRepositoryImpl[T]) CreateMany(
+func (r *BaseRepositoryImpl[T, ID]) CreateMany(
 	ctx context.Context,
 	entities []*T,
 	modifier func(*gorm.DB) *gorm.DB,
 ) error {
-	q := r.db.WithContext(ctx)
+	q := r.writer(ctx)
 	if modifier != nil {
 		q = modifier(q)
 	}
@@ -122,13 +216,13 @@ func (r *BaseRepositoryImpl[T]) CreateMany(
 }
 
 // ---- UPDATE ----
-func (r *BaseRepositoryImpl[T]) UpdateOne(
+func (r *BaseRepositoryImpl[T, ID]) UpdateOne(
 	ctx context.Context,
-	id uint,
+	id ID,
 	entity *T,
 	modifier func(*gorm.DB) *gorm.DB,
 ) error {
-	q := r.db.WithContext(ctx).Model(new(T)).Where("id = ?", id)
+	q := r.writer(ctx).Model(new(T)).Where("id = ?", id)
 	if modifier != nil {
 		q = modifier(q)
 	}
@@ -144,12 +238,12 @@ func (r *BaseRepositoryImpl[T]) UpdateOne(
 	return nil
 }
 
-func (r *BaseRepositoryImpl[T]) UpdateMany(
+func (r *BaseRepositoryImpl[T, ID]) UpdateMany(
 	ctx context.Context,
 	entities []*T,
 	modifier func(*gorm.DB) *gorm.DB,
 ) error {
-	q := r.db.WithContext(ctx)
+	q := r.writer(ctx)
 	if modifier != nil {
 		q = modifier(q)
 	}
@@ -165,13 +259,13 @@ func (r *BaseRepositoryImpl[T]) UpdateMany(
 	return nil
 }
 
-func (r *BaseRepositoryImpl[T]) PatchOne(
+func (r *BaseRepositoryImpl[T, ID]) PatchOne(
 	ctx context.Context,
-	id uint,
+	id ID,
 	updates map[string]any,
 	modifier func(*gorm.DB) *gorm.DB,
 ) error {
-	q := r.db.WithContext(ctx).Model(new(T)).Where("id = ?", id)
+	q := r.writer(ctx).Model(new(T)).Where("id = ?", id)
 	if modifier != nil {
 		q = modifier(q)
 	}
@@ -188,8 +282,8 @@ func (r *BaseRepositoryImpl[T]) PatchOne(
 }
 
 // ---- DELETE ----
-func (r *BaseRepositoryImpl[T]) DeleteOne(ctx context.Context, id uint) error {
-	result := r.db.WithContext(ctx).Delete(new(T), id)
+func (r *BaseRepositoryImpl[T, ID]) DeleteOne(ctx context.Context, id ID) error {
+	result := r.writer(ctx).Where("id = ?", id).Delete(new(T))
 	if result.Error != nil {
 		return result.Error
 	}
@@ -199,8 +293,8 @@ func (r *BaseRepositoryImpl[T]) DeleteOne(ctx context.Context, id uint) error {
 	return nil
 }
 
-func (r *BaseRepositoryImpl[T]) DeleteMany(ctx context.Context, modifier func(*gorm.DB) *gorm.DB) error {
-	q := r.db.WithContext(ctx).Model(new(T))
+func (r *BaseRepositoryImpl[T, ID]) DeleteMany(ctx context.Context, modifier func(*gorm.DB) *gorm.DB) error {
+	q := r.writer(ctx).Model(new(T))
 	if modifier != nil {
 		q = modifier(q)
 	}
@@ -217,13 +311,13 @@ func (r *BaseRepositoryImpl[T]) DeleteMany(ctx context.Context, modifier func(*g
 }
 
 // ---- UPSERT ----
-func (r *BaseRepositoryImpl[T]) Upsert(
+func (r *BaseRepositoryImpl[T, ID]) Upsert(
 	ctx context.Context,
 	entity *T,
 	conflictColumns []clause.Column,
 	modifier func(*gorm.DB) *gorm.DB,
 ) error {
-	q := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+	q := r.writer(ctx).Clauses(clause.OnConflict{
 		Columns:   conflictColumns,
 		UpdateAll: true,
 	})
@@ -233,10 +327,16 @@ func (r *BaseRepositoryImpl[T]) Upsert(
 	return q.Create(entity).Error
 }
 
-func (r *BaseRepositoryImpl[T]) WithTx(tx *gorm.DB) BaseRepository[T] {
-	return &BaseRepositoryImpl[T]{db: tx}
+func (r *BaseRepositoryImpl[T, ID]) WithTx(tx *gorm.DB) BaseRepository[T, ID] {
+	return &BaseRepositoryImpl[T, ID]{db: tx}
 }
 
-func (r *BaseRepositoryImpl[T]) DB() *gorm.DB {
+func (r *BaseRepositoryImpl[T, ID]) DB() *gorm.DB {
 	return r.db
 }
+
+// writer marks the request as having written, so its next reads stick to the primary.
+func (r *BaseRepositoryImpl[T, ID]) writer(ctx context.Context) *gorm.DB {
+	database.MarkWrite(ctx)
+	return r.db.WithContext(ctx)
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SearchOptions struct {
+	Term      string
+	Columns   []string // text columns matched with ILIKE / trigram, the first one is highlighted
+	Vector    string   // tsvector column (ex: "search_vector"), empty = no full-text search
+	Config    string   // text search configuration, default "simple"
+	Prefix    bool     // full-text words match as prefix: "jo sm" -> 'jo':* & 'sm':*
+	Fuzzy     bool     // match by pg_trgm word similarity, tolerates typos
+	Highlight bool     // select ts_headline of Columns[0] AS highlight, HTML-escaped except the <mark> tags
+	Unranked  bool     // filter only, no relevance ORDER BY (for Stream / FindInBatches)
+}
+
+// Search returns a modifier for GetAll / Stream / FindInBatches. Results are
+// ordered by relevance, set Unranked to use it with Stream / FindInBatches
+// (keyset by id).
+//
+// Full-text needs a tsvector column and fuzzy needs the pg_trgm extension,
+// see migrations/*_add-users-search.up.sql for an example.
+func Search(opts SearchOptions) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		term := strings.TrimSpace(opts.Term)
+		if term == "" {
+			return db
+		}
+		if opts.Config == "" {
+			opts.Config = "simple"
+		}
+
+		query := tsQuery(opts.Config, term, opts.Prefix)
+		conds := make([]string, 0, len(opts.Columns)+1)
+		vars := make([]any, 0, 2*len(opts.Columns)+1)
+		ranks := make([]string, 0, len(opts.Columns)+1)
+		rankVars := make([]any, 0, 2*len(opts.Columns)+1)
+
+		if opts.Vector != "" {
+			vector := clause.Column{Name: opts.Vector}
+			conds = append(conds, "? @@ ?")
+			vars = append(vars, vector, query)
+			ranks = append(ranks, "ts_rank(?, ?)")
+			rankVars = append(rankVars, vector, query)
+		}
+
+		for _, col := range opts.Columns {
+			column := clause.Column{Name: col}
+			if opts.Fuzzy {
+				// <% bisa pakai GIN gin_trgm_ops index
+				conds = append(conds, "? <% ?")
+				vars = append(vars, term, column)
+				ranks = append(ranks, "word_similarity(?, ?)")
+				rankVars = append(rankVars, term, column)
+			} else {
+				conds = append(conds, "? ILIKE ?")
+				vars = append(vars, column, "%"+escapeLike(term)+"%")
+			}
+		}
+
+		if len(conds) == 0 {
+			return db
+		}
+		db = db.Where(clause.Expr{SQL: "(" + strings.Join(conds, " OR ") + ")", Vars: vars})
+
+		if len(ranks) > 0 && !opts.Unranked {
+			db = db.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:                strings.Join(ranks, " + ") + " DESC",
+				Vars:               rankVars,
+				WithoutParentheses: true,
+			}})
+		}
+
+		if opts.Highlight && len(opts.Columns) > 0 {
+			// escape dulu, client merender highlight sebagai HTML
+			db = db.Select("?.*, ts_headline(?::regconfig, "+htmlEscapeSQL+", ?, 'StartSel=<mark>, StopSel=</mark>') AS highlight",
+				clause.Table{Name: clause.CurrentTable}, opts.Config, clause.Column{Name: opts.Columns[0]}, query)
+		}
+
+		return db
+	}
+}
+
+// tsQuery builds websearch_to_tsquery for plain terms, or a prefix query made
+// only of letters and digits (to_tsquery syntax errors on user input otherwise).
+func tsQuery(config, term string, prefix bool) clause.Expr {
+	if !prefix {
+		return clause.Expr{SQL: "websearch_to_tsquery(?::regconfig, ?)", Vars: []any{config, term}}
+	}
+
+	words := strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return clause.Expr{SQL: "to_tsquery(?::regconfig, ?)", Vars: []any{config, strings.Join(words, " & ")}}
+}
+
+// htmlEscapeSQL escapes the column bound to its ? like html.EscapeString.
+const htmlEscapeSQL = `replace(replace(replace(replace(replace(coalesce(?, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,73 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header carries the request id in and out of the API.
+const Header = "X-Request-ID"
+
+type key struct{}
+
+// Key is the c.Locals key of the request id. Locals live in the fasthttp
+// context, so From(c.Context()) finds it in services and repositories.
+var Key = key{}
+
+// With returns ctx carrying id, for contexts not derived from a request (ex: jobs).
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, Key, id)
+}
+
+// From returns the request id in ctx, empty outside a request.
+func From(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(Key).(string)
+	return id
+}
+
+// Valid accepts ids a client may send: 1-128 characters of [A-Za-z0-9._:-],
+// anything else could break log lines.
+func Valid(id string) bool {
+	if len(id) == 0 || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Transport forwards the request id of the outgoing request's context.
+type Transport struct {
+	Base http.RoundTripper // default http.DefaultTransport
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	id := From(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return base.RoundTrip(req)
+	}
+	// RoundTripper tidak boleh mengubah request asli
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return base.RoundTrip(req)
+}
+
+// Client is an http.Client for outbound calls, pass the request context:
+//
+//	req, _ := http.NewRequestWithContext(c.Context(), http.MethodGet, url, nil)
+//	resp, err := requestid.Client.Do(req)
+var Client = &http.Client{Transport: &Transport{}}
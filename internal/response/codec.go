@@ -0,0 +1,151 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"mime"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/tinylib/msgp/msgp"
+)
+
+const (
+	MIMEMsgPack = "application/msgpack"
+	MIMECBOR    = "application/cbor"
+)
+
+// Formats are the media types responses can be encoded in, JSON first so it
+// wins when Accept is missing or */*.
+var Formats = []string{fiber.MIMEApplicationJSON, MIMEMsgPack, MIMECBOR}
+
+// BodyTypes are the request Content-Types handlers can parse.
+var BodyTypes = []string{fiber.MIMEApplicationJSON, MIMEMsgPack, MIMECBOR, fiber.MIMEApplicationForm, fiber.MIMEMultipartForm}
+
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// msgpack has no registered media type, clients send any of these
+var msgpackAliases = []string{MIMEMsgPack, "application/x-msgpack", "application/vnd.msgpack"}
+
+var cborDecode, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))}.DecMode()
+
+// keys sorted so the same document always gives the same bytes (strong ETags)
+var cborEncode, _ = cbor.EncOptions{Sort: cbor.SortBytewiseLexical}.EncMode()
+
+// MediaType returns the lowercase media type of a Content-Type, msgpack aliases
+// folded into MIMEMsgPack.
+func MediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	for _, alias := range msgpackAliases {
+		if mediaType == alias {
+			return MIMEMsgPack
+		}
+	}
+	return mediaType
+}
+
+// Encode converts a JSON body to format. It goes through the JSON form so
+// json tags and MarshalJSON (utils.Nullable, Problem, ...) give the same
+// document in every format.
+func Encode(format string, body []byte) ([]byte, error) {
+	if format == fiber.MIMEApplicationJSON {
+		return body, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	doc = numbers(doc)
+
+	switch format {
+	case MIMEMsgPack:
+		return appendMsgpack(nil, doc)
+	case MIMECBOR:
+		return cborEncode.Marshal(doc)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// Decode converts a request body in mediaType to JSON.
+func Decode(mediaType string, body []byte) ([]byte, error) {
+	var doc any
+	switch mediaType {
+	case fiber.MIMEApplicationJSON:
+		return body, nil
+	case MIMEMsgPack:
+		v, _, err := msgp.ReadIntfBytes(body)
+		if err != nil {
+			return nil, err
+		}
+		doc = v
+	case MIMECBOR:
+		if err := cborDecode.Unmarshal(body, &doc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	return json.Marshal(doc)
+}
+
+// appendMsgpack is msgp.AppendIntf with map keys sorted.
+func appendMsgpack(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		b = msgp.AppendMapHeader(b, uint32(len(v)))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			b = msgp.AppendString(b, k)
+			var err error
+			if b, err = appendMsgpack(b, v[k]); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case []any:
+		b = msgp.AppendArrayHeader(b, uint32(len(v)))
+		for _, item := range v {
+			var err error
+			if b, err = appendMsgpack(b, item); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	}
+	return msgp.AppendIntf(b, v)
+}
+
+// numbers turns json.Number into int64 / uint64 / float64, ids stay integers.
+func numbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = numbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return n
+		}
+		n, _ := v.Float64()
+		return n
+	}
+	return v
+}
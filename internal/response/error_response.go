@@ -1,30 +1,55 @@
 package response
 
 import (
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/requestid"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/logger"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/sirupsen/logrus"
 )
 
 func Error(c *fiber.Ctx, statusCode int, message string, details interface{}) error {
 	var errRes error
 	if details != nil {
-		errRes = c.Status(statusCode).JSON(ErrorDetails{
-			Code:    statusCode,
-			Status:  "error",
-			Message: message,
-			Errors:  details,
+		errRes = send(c, statusCode, ErrorDetails{
+			Code:      statusCode,
+			Status:    "error",
+			Message:   message,
+			Errors:    details,
+			RequestID: requestid.From(c.Context()),
 		})
 	} else {
-		errRes = c.Status(statusCode).JSON(Common{
-			Code:    statusCode,
-			Status:  "error",
-			Message: message,
+		errRes = send(c, statusCode, Common{
+			Code:      statusCode,
+			Status:    "error",
+			Message:   message,
+			RequestID: requestid.From(c.Context()),
 		})
 	}
 
 	if errRes != nil {
-		logrus.Errorf("Failed to send error response : %+v", errRes)
+		logger.Log.WithContext(c.UserContext()).Errorf("Failed to send error response : %+v", errRes)
 	}
 
 	return errRes
 }
+
+// send writes v in the format middleware.Negotiate would pick, error responses
+// are rendered after it ran.
+func send(c *fiber.Ctx, statusCode int, v any) error {
+	format := c.Accepts(Formats...)
+	if format == "" || format == fiber.MIMEApplicationJSON {
+		return c.Status(statusCode).JSON(v)
+	}
+
+	raw, err := c.App().Config().JSONEncoder(v)
+	if err != nil {
+		return err
+	}
+	body, err := Encode(format, raw)
+	if err != nil {
+		return err
+	}
+	c.Vary(fiber.HeaderAccept)
+	c.Set(fiber.HeaderContentType, format)
+	return c.Status(statusCode).Send(body)
+}
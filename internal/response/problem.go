@@ -0,0 +1,193 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/requestid"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProblemContentType is the RFC 9457 media type.
+const ProblemContentType = "application/problem+json"
+
+var (
+	// ProblemDefault makes problem+json the default error format, otherwise
+	// clients opt in with "Accept: application/problem+json".
+	ProblemDefault bool
+	// ProblemTypeBase prefixes every registered code to build its type URI.
+	ProblemTypeBase = "/api/problems/"
+)
+
+// ProblemType is one entry of the error registry. Code is stable and meant
+// for clients to branch on, Title is the same for every occurrence.
+type ProblemType struct {
+	Code   string `json:"code"`
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+}
+
+func (t ProblemType) URI() string {
+	if t.Code == "" {
+		return "about:blank"
+	}
+	return ProblemTypeBase + t.Code
+}
+
+var (
+	problemMu    sync.RWMutex
+	problemTypes = map[string]ProblemType{}
+)
+
+// RegisterProblem adds t to the registry, call it from a package level var:
+//
+//	var ErrEmailTaken = response.RegisterProblem(response.ProblemType{Code: "email_taken", Status: 409, Title: "Email already registered"})
+func RegisterProblem(t ProblemType) ProblemType {
+	if t.Title == "" {
+		t.Title = http.StatusText(t.Status)
+	}
+
+	problemMu.Lock()
+	defer problemMu.Unlock()
+	if _, ok := problemTypes[t.Code]; ok {
+		panic("response: problem type " + t.Code + " registered twice")
+	}
+	problemTypes[t.Code] = t
+	return t
+}
+
+func LookupProblem(code string) (ProblemType, bool) {
+	problemMu.RLock()
+	defer problemMu.RUnlock()
+	t, ok := problemTypes[code]
+	return t, ok
+}
+
+// ProblemTypes returns the registry sorted by code.
+func ProblemTypes() []ProblemType {
+	problemMu.RLock()
+	defer problemMu.RUnlock()
+	types := make([]ProblemType, 0, len(problemTypes))
+	for _, t := range problemTypes {
+		types = append(types, t)
+	}
+	slices.SortFunc(types, func(a, b ProblemType) int { return strings.Compare(a.Code, b.Code) })
+	return types
+}
+
+// generic types, used when an error carries no code of its own
+var (
+	ProblemBadRequest           = RegisterProblem(ProblemType{Code: "bad_request", Status: fiber.StatusBadRequest})
+	ProblemValidation           = RegisterProblem(ProblemType{Code: "validation_failed", Status: fiber.StatusBadRequest, Title: "Validation Failed"})
+	ProblemUnauthorized         = RegisterProblem(ProblemType{Code: "unauthorized", Status: fiber.StatusUnauthorized})
+	ProblemPaymentRequired      = RegisterProblem(ProblemType{Code: "payment_required", Status: fiber.StatusPaymentRequired})
+	ProblemForbidden            = RegisterProblem(ProblemType{Code: "forbidden", Status: fiber.StatusForbidden})
+	ProblemNotFound             = RegisterProblem(ProblemType{Code: "not_found", Status: fiber.StatusNotFound})
+	ProblemMethodNotAllowed     = RegisterProblem(ProblemType{Code: "method_not_allowed", Status: fiber.StatusMethodNotAllowed})
+	ProblemNotAcceptable        = RegisterProblem(ProblemType{Code: "not_acceptable", Status: fiber.StatusNotAcceptable})
+	ProblemConflict             = RegisterProblem(ProblemType{Code: "conflict", Status: fiber.StatusConflict})
+	ProblemGone                 = RegisterProblem(ProblemType{Code: "gone", Status: fiber.StatusGone})
+	ProblemPreconditionFailed   = RegisterProblem(ProblemType{Code: "precondition_failed", Status: fiber.StatusPreconditionFailed})
+	ProblemPayloadTooLarge      = RegisterProblem(ProblemType{Code: "payload_too_large", Status: fiber.StatusRequestEntityTooLarge})
+	ProblemUnsupportedMedia     = RegisterProblem(ProblemType{Code: "unsupported_media_type", Status: fiber.StatusUnsupportedMediaType})
+	ProblemUnprocessable        = RegisterProblem(ProblemType{Code: "unprocessable_entity", Status: fiber.StatusUnprocessableEntity})
+	ProblemPreconditionRequired = RegisterProblem(ProblemType{Code: "precondition_required", Status: fiber.StatusPreconditionRequired})
+	ProblemTooManyRequests      = RegisterProblem(ProblemType{Code: "too_many_requests", Status: fiber.StatusTooManyRequests})
+	ProblemInternal             = RegisterProblem(ProblemType{Code: "internal_error", Status: fiber.StatusInternalServerError})
+	ProblemBadGateway           = RegisterProblem(ProblemType{Code: "bad_gateway", Status: fiber.StatusBadGateway})
+	ProblemUnavailable          = RegisterProblem(ProblemType{Code: "service_unavailable", Status: fiber.StatusServiceUnavailable})
+)
+
+// ProblemForStatus returns the generic type of an HTTP status.
+func ProblemForStatus(status int) ProblemType {
+	for _, t := range []ProblemType{
+		ProblemBadRequest, ProblemUnauthorized, ProblemPaymentRequired, ProblemForbidden,
+		ProblemNotFound, ProblemMethodNotAllowed, ProblemNotAcceptable, ProblemConflict,
+		ProblemGone, ProblemPreconditionFailed, ProblemPayloadTooLarge, ProblemUnsupportedMedia,
+		ProblemUnprocessable, ProblemPreconditionRequired, ProblemTooManyRequests,
+		ProblemInternal, ProblemBadGateway, ProblemUnavailable,
+	} {
+		if t.Status == status {
+			return t
+		}
+	}
+	return ProblemType{Status: status, Title: http.StatusText(status)}
+}
+
+// Coder is implemented by errors that carry a registered problem code.
+type Coder interface {
+	ErrorCode() string
+}
+
+// Problem is an RFC 9457 problem details body. Extensions are written as
+// top level members next to the standard ones.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Code       string
+	RequestID  string
+	Extensions map[string]any
+}
+
+func NewProblem(c *fiber.Ctx, t ProblemType, detail string) Problem {
+	return Problem{
+		Type:      t.URI(),
+		Title:     t.Title,
+		Status:    t.Status,
+		Detail:    detail,
+		Instance:  c.OriginalURL(),
+		Code:      t.Code,
+		RequestID: requestid.From(c.Context()),
+	}
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	body := make(map[string]any, len(p.Extensions)+7)
+	for k, v := range p.Extensions {
+		body[k] = v
+	}
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	if p.Detail != "" {
+		body["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		body["instance"] = p.Instance
+	}
+	if p.Code != "" {
+		body["code"] = p.Code
+	}
+	if p.RequestID != "" {
+		body["request_id"] = p.RequestID
+	}
+	return json.Marshal(body)
+}
+
+// WantsProblem reports whether the error response should be problem+json.
+func WantsProblem(c *fiber.Ctx) bool {
+	if ProblemDefault {
+		return true
+	}
+	return c.Accepts(fiber.MIMEApplicationJSON, ProblemContentType) == ProblemContentType
+}
+
+func WriteProblem(c *fiber.Ctx, p Problem) error {
+	raw, err := json.Marshal(p)
+	if err == nil {
+		c.Set(fiber.HeaderContentType, ProblemContentType)
+		err = c.Status(p.Status).Send(raw)
+	}
+	if err != nil {
+		logger.Log.WithContext(c.UserContext()).Errorf("Failed to send error response : %+v", err)
+	}
+	return err
+}
@@ -1,9 +1,10 @@
 package response
 
 type Common struct {
-	Code    int    `json:"code"`
-	Status  string `json:"status"`
-	Message string `json:"message"`
+	Code      int    `json:"code"`
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type Success struct {
@@ -29,8 +30,9 @@ type SuccessWithPaginate[T any] struct {
 }
 
 type ErrorDetails struct {
-	Code    int         `json:"code"`
-	Status  string      `json:"status"`
-	Message string      `json:"message"`
-	Errors  interface{} `json:"errors"`
+	Code      int         `json:"code"`
+	Status    string      `json:"status"`
+	Message   string      `json:"message"`
+	Errors    interface{} `json:"errors"`
+	RequestID string      `json:"request_id,omitempty"`
 }
@@ -0,0 +1,57 @@
+package response
+
+import (
+	"bufio"
+	"encoding/json"
+	"iter"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// flushEvery controls how many rows are buffered before they are pushed to the client.
+const flushEvery = 100
+
+// Stream writes the body with fn after the handler returns. fn must not touch
+// *fiber.Ctx (it is already released), capture what it needs beforehand.
+func Stream(c *fiber.Ctx, contentType string, fn func(w *bufio.Writer) error) error {
+	c.Set(fiber.HeaderContentType, contentType)
+	ctx := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := fn(w); err != nil {
+			// status & header sudah terkirim, cukup log
+			logger.Log.WithContext(ctx).Errorf("Stream aborted : %+v", err)
+			return
+		}
+		if err := w.Flush(); err != nil {
+			logger.Log.WithContext(ctx).Errorf("Stream flush failed : %+v", err)
+		}
+	})
+	return nil
+}
+
+// NDJSON streams rows as newline delimited JSON, ex:
+//
+//	return response.NDJSON(c, repo.Stream(ctx, 1000, nil))
+func NDJSON[T any](c *fiber.Ctx, rows iter.Seq2[T, error]) error {
+	return Stream(c, "application/x-ndjson", func(w *bufio.Writer) error {
+		enc := json.NewEncoder(w)
+		n := 0
+		for row, err := range rows {
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+			if n++; n%flushEvery == 0 {
+				// gagal flush = client sudah disconnect, stop query
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
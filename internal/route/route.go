@@ -1,31 +1,64 @@
 package route
 
 import (
+	"context"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/httpcache"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/idempotency"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/metering"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/ratelimit"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
+	files "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/files"
 	users "github.com/hafizhproject45/Golang-Boilerplate.git/internal/modules/users"
 	// MODULE IMPORTS
 )
 
-func Routes(app *fiber.App, db *gorm.DB) {
-	validate := validation.Validator()
+func Routes(app *fiber.App, db *gorm.DB, rdb *redis.Client) {
+	validation.UseDB(db)
+	httpcache.UseRedis(rdb)
+	ratelimit.UseRedis(rdb)
+	metering.UseRedis(rdb)
+	idempotency.UseRedis(rdb)
+	metering.UseDB(db)
+	validate, err := validation.Validator()
+	if err != nil {
+		utils.Log.Fatalf("Validator init failed: %v", err)
+	}
 	api := app.Group("/api")
 
 	// masterRoute.Routes(api, db)
 
-	// root modules di sini
-	allModules := []modules.Module{
-		users.UserModule{},
-		// MODULE REGISTRY
-	}
+	// problem type URIs (RFC 9457) resolve here
+	api.Get("/problems/:code?", utils.ProblemTypesHandler)
 
 	// daftarkan root modules
-	for _, m := range allModules {
-		m.RegisterRoutes(api, db, validate)
+	for _, m := range allModules() {
+		m.RegisterRoutes(api, db, rdb, validate)
 	}
 
 }
+
+// Background starts the background loops of the modules, see modules.Background.
+func Background(ctx context.Context, db *gorm.DB, rdb *redis.Client) {
+	for _, m := range allModules() {
+		if b, ok := m.(modules.Background); ok {
+			go b.RunBackground(ctx, db, rdb)
+		}
+	}
+}
+
+// root modules di sini
+func allModules() []modules.Module {
+	return []modules.Module{
+		users.UserModule{},
+		files.FileModule{},
+		// MODULE REGISTRY
+	}
+}
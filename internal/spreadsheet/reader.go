@@ -0,0 +1,127 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrUnsupported = errors.New("spreadsheet: only CSV and XLSX files are supported")
+
+// Row is one data row keyed by the mapped column name. Line is the 1-based
+// line of the row in the source file (the header is line 1).
+type Row struct {
+	Line   int
+	Values map[string]string
+}
+
+type Sheet struct {
+	Columns []string // mapped header names, in file order
+	Rows    []Row
+}
+
+// Mapping translates a source header to a field name. Headers are compared
+// with Normalize, unmapped headers fall back to their normalized form.
+type Mapping map[string]string
+
+// Read parses a CSV or XLSX file (detected from content). The first row is
+// the header, empty rows are skipped.
+func Read(data []byte, mapping Mapping) (*Sheet, error) {
+	var records [][]string
+	var err error
+
+	mtype := mimetype.Detect(data)
+	switch {
+	case mtype.Is("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"):
+		records, err = readXLSX(data)
+	case mtype.Is("text/csv"), mtype.Is("text/plain"):
+		records, err = readCSV(data)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("spreadsheet: file is empty")
+	}
+
+	header := make([]string, len(records[0]))
+	columns := make([]string, 0, len(header))
+	normalized := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		normalized[Normalize(from)] = to
+	}
+	for i, h := range records[0] {
+		key := Normalize(h)
+		if to, ok := normalized[key]; ok {
+			key = to
+		}
+		header[i] = key
+		if key != "" {
+			columns = append(columns, key)
+		}
+	}
+
+	rows := make([]Row, 0, len(records)-1)
+	for i, record := range records[1:] {
+		values := make(map[string]string, len(header))
+		empty := true
+		for j, name := range header {
+			if name == "" || j >= len(record) {
+				continue
+			}
+			v := strings.TrimSpace(record[j])
+			if v != "" {
+				empty = false
+			}
+			values[name] = v
+		}
+		if empty {
+			continue
+		}
+		rows = append(rows, Row{Line: i + 2, Values: values})
+	}
+	return &Sheet{Columns: columns, Rows: rows}, nil
+}
+
+// Normalize turns a header like " E-mail Address " into "e_mail_address",
+// anything but ascii letters and digits (BOM included) is a separator.
+func Normalize(header string) string {
+	fields := strings.FieldsFunc(strings.ToLower(header), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(fields, "_")
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	// Excel versi lokal (id, de, ...) menyimpan CSV dengan ";"
+	if first, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		r.Comma = ';'
+	}
+	return r.ReadAll()
+}
+
+// readXLSX reads the first sheet of the workbook.
+func readXLSX(data []byte) ([][]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return f.GetRows(sheets[0])
+}
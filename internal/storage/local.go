@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// typeSuffix is the sidecar file holding the content type of an object.
+const typeSuffix = ".content-type"
+
+// LocalDriver stores objects on the filesystem under root. Downloads go
+// through the API (GET baseURL/<key>), guarded by an HMAC signature.
+// The content type given to Put is kept next to the object in <file>.content-type.
+type LocalDriver struct {
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+func NewLocalDriver(root, baseURL, secret string) (*LocalDriver, error) {
+	if secret == "" {
+		return nil, errors.New("storage: signing key is required for the local driver")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalDriver{root: root, baseURL: baseURL, secret: []byte(secret)}, nil
+}
+
+func (d *LocalDriver) path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+func (d *LocalDriver) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+
+	// tulis ke file sementara dulu, rename atomic supaya reader tidak lihat file setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && n != size {
+		return nil, fmt.Errorf("storage: wrote %d bytes, expected %d", n, size)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// tipe hasil sniff disimpan, bukan ditebak lagi dari ekstensi
+	if err := os.WriteFile(p+typeSuffix, []byte(contentType), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return nil, err
+	}
+
+	return d.Stat(ctx, key)
+}
+
+func (d *LocalDriver) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	obj, err := d.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, _ := d.path(key)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, obj, nil
+}
+
+func (d *LocalDriver) Stat(_ context.Context, key string) (*Object, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, _ = CleanKey(key)
+	contentType := "application/octet-stream"
+	if raw, err := os.ReadFile(p + typeSuffix); err == nil {
+		contentType = string(raw)
+	} else if byExt := mime.TypeByExtension(filepath.Ext(p)); byExt != "" {
+		// object lama tanpa sidecar
+		contentType = byExt
+	}
+	return &Object{Key: key, Size: info.Size(), ContentType: contentType, ModifiedAt: info.ModTime()}, nil
+}
+
+func (d *LocalDriver) Delete(_ context.Context, key string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(p + typeSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (d *LocalDriver) SignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(ttl).Unix()
+
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", d.sign(key, expires))
+	return d.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode(), nil
+}
+
+// Verify checks a signature produced by SignedURL.
+func (d *LocalDriver) Verify(key, expires, signature string) bool {
+	key, err := CleanKey(key)
+	if err != nil {
+		return false
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(d.sign(key, exp)), []byte(signature))
+}
+
+func (d *LocalDriver) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, d.secret)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/requestid"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Options struct {
+	Endpoint  string // host[:port] without scheme, ex: "s3.amazonaws.com" or "minio:9000"
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string
+	UseSSL    bool
+}
+
+// S3Driver works with any S3 compatible service (AWS S3, MinIO, R2, ...).
+type S3Driver struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Driver(opts S3Options) (*S3Driver, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("storage: s3 endpoint and bucket are required")
+	}
+
+	transport, err := minio.DefaultTransport(opts.UseSSL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure:    opts.UseSSL,
+		Region:    opts.Region,
+		Transport: &requestid.Transport{Base: transport},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, opts.Bucket, minio.MakeBucketOptions{Region: opts.Region}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &S3Driver{client: client, bucket: opts.Bucket}, nil
+}
+
+func (d *S3Driver) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := d.client.PutObject(ctx, d.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return nil, err
+	}
+	return &Object{Key: key, Size: info.Size, ContentType: contentType, ModifiedAt: time.Now()}, nil
+}
+
+func (d *S3Driver) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	obj, err := d.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := d.client.GetObject(ctx, d.bucket, obj.Key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, translate(err)
+	}
+	return r, obj, nil
+}
+
+func (d *S3Driver) Stat(ctx context.Context, key string) (*Object, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := d.client.StatObject(ctx, d.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &Object{Key: key, Size: info.Size, ContentType: info.ContentType, ModifiedAt: info.LastModified}, nil
+}
+
+func (d *S3Driver) Delete(ctx context.Context, key string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	return translate(d.client.RemoveObject(ctx, d.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (d *S3Driver) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	// sama seperti download driver local: tidak pernah dirender inline oleh browser
+	params := url.Values{}
+	params.Set("response-content-disposition", "attachment")
+	u, err := d.client.PresignedGetObject(ctx, d.bucket, key, ttl, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
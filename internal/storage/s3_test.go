@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+)
+
+// pngHeader is enough for mimetype to sniff image/png.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+// newFakeS3 starts an in-memory S3 stand-in (in place of MinIO) and returns a
+// driver connected to it.
+func newFakeS3(t *testing.T) *S3Driver {
+	t.Helper()
+
+	srv := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+	t.Cleanup(srv.Close)
+
+	driver, err := NewS3Driver(S3Options{
+		Endpoint:  strings.TrimPrefix(srv.URL, "http://"),
+		AccessKey: "test",
+		SecretKey: "test",
+		Bucket:    "uploads",
+		Region:    "us-east-1",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Driver: %v", err)
+	}
+	return driver
+}
+
+func TestS3Driver(t *testing.T) {
+	driver := newFakeS3(t)
+	ctx := context.Background()
+
+	obj, err := driver.Put(ctx, "/uploads/1/../1/a.png", bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if obj.Key != "uploads/1/a.png" {
+		t.Errorf("Put key = %q, want cleaned uploads/1/a.png", obj.Key)
+	}
+
+	stat, err := driver.Stat(ctx, obj.Key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if stat.ContentType != "image/png" || stat.Size != int64(len(pngHeader)) {
+		t.Errorf("Stat = %s %d bytes, want image/png %d bytes", stat.ContentType, stat.Size, len(pngHeader))
+	}
+
+	r, _, err := driver.Get(ctx, obj.Key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(body, pngHeader) {
+		t.Errorf("Get body = %q, %v", body, err)
+	}
+
+	signed, err := driver.SignedURL(ctx, obj.Key, time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("SignedURL %q: %v", signed, err)
+	}
+	if got := u.Query().Get("response-content-disposition"); got != "attachment" {
+		t.Errorf("SignedURL response-content-disposition = %q, want attachment", got)
+	}
+
+	if err := driver.Delete(ctx, obj.Key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := driver.Stat(ctx, obj.Key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUploadAllowList(t *testing.T) {
+	driver := newFakeS3(t)
+
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		obj, err := Upload(c.Context(), c, driver, "uploads/1", UploadOptions{})
+		if err != nil {
+			return err
+		}
+		return c.JSON(obj)
+	})
+
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		status   int
+		mimeType string
+	}{
+		{"png", "avatar.png", pngHeader, fiber.StatusOK, "image/png"},
+		{"html renamed to png", "avatar.png", []byte("<!DOCTYPE html><html><script>alert(1)</script></html>"), fiber.StatusUnsupportedMediaType, ""},
+		{"svg", "logo.svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"></svg>`), fiber.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := multipart.NewWriter(&buf)
+			part, _ := w.CreateFormFile("file", tt.filename)
+			part.Write(tt.content)
+			w.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/", &buf)
+			req.Header.Set(fiber.HeaderContentType, w.FormDataContentType())
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				body, _ := io.ReadAll(resp.Body)
+				t.Fatalf("status = %d (%s), want %d", resp.StatusCode, body, tt.status)
+			}
+			if tt.mimeType == "" {
+				return
+			}
+
+			key := uploadedKey(t, resp.Body)
+			obj, err := driver.Stat(context.Background(), key)
+			if err != nil {
+				t.Fatalf("Stat %s: %v", key, err)
+			}
+			if obj.ContentType != tt.mimeType || !strings.HasSuffix(key, ".png") {
+				t.Errorf("stored %s as %s, want %s with a sniffed extension", key, obj.ContentType, tt.mimeType)
+			}
+		})
+	}
+}
+
+func TestLocalDriverKeepsContentType(t *testing.T) {
+	driver, err := NewLocalDriver(t.TempDir(), "http://localhost/api/files/raw", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalDriver: %v", err)
+	}
+	ctx := context.Background()
+
+	// ekstensi menyesatkan, tipe hasil sniff yang dipakai
+	if _, err := driver.Put(ctx, "uploads/1/a.html", bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	obj, err := driver.Stat(ctx, "uploads/1/a.html")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if obj.ContentType != "image/png" {
+		t.Errorf("Stat content type = %q, want image/png", obj.ContentType)
+	}
+}
+
+func TestSafeContentType(t *testing.T) {
+	tests := map[string]string{
+		"image/png":                 "image/png",
+		"text/plain; charset=utf-8": "text/plain; charset=utf-8",
+		"text/html; charset=utf-8":  "application/octet-stream",
+		"image/svg+xml":             "application/octet-stream",
+		"application/xhtml+xml":     "application/octet-stream",
+		"":                          "application/octet-stream",
+	}
+	for in, want := range tests {
+		if got := SafeContentType(in); got != want {
+			t.Errorf("SafeContentType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func uploadedKey(t *testing.T, body io.Reader) string {
+	t.Helper()
+	var obj Object
+	raw, _ := io.ReadAll(body)
+	if err := fiber.New().Config().JSONDecoder(raw, &obj); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	return obj.Key
+}
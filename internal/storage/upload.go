@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"slices"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/config"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImageTypes is the allow list used for avatars.
+var ImageTypes = []string{"image/jpeg", "image/png", "image/webp", "image/gif"}
+
+type UploadOptions struct {
+	Field        string   // multipart field name, default "file"
+	MaxSize      int64    // bytes, 0 = config.UploadMaxSize
+	AllowedTypes []string // sniffed MIME types, empty = config.UploadAllowedTypes
+}
+
+// Upload reads the multipart file opts.Field from the request, checks its
+// size and sniffed content type, then stores it under prefix.
+//
+// The client supplied Content-Type and file name are ignored, the MIME type
+// comes from the file content (mimetype), so a renamed .exe is not an image.
+func Upload(ctx context.Context, c *fiber.Ctx, driver Driver, prefix string, opts UploadOptions) (*Object, error) {
+	if opts.Field == "" {
+		opts.Field = "file"
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = config.UploadMaxSize
+	}
+	if len(opts.AllowedTypes) == 0 {
+		opts.AllowedTypes = config.UploadAllowedTypes
+	}
+
+	fh, err := c.FormFile(opts.Field)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Field "+opts.Field+" must be a file")
+	}
+	if fh.Size > opts.MaxSize {
+		return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge, "File is too large")
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mtype, err := sniff(f)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(opts.AllowedTypes, mtype.Is) {
+		return nil, fiber.NewError(fiber.StatusUnsupportedMediaType, "File type "+mtype.String()+" is not allowed")
+	}
+
+	return driver.Put(ctx, NewKey(prefix, mtype.Extension()), f, fh.Size, mtype.String())
+}
+
+func sniff(f multipart.File) (*mimetype.MIME, error) {
+	mtype, err := mimetype.DetectReader(f)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return mtype, nil
+}
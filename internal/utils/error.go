@@ -2,26 +2,114 @@ package utils
 
 import (
 	"errors"
+	"strings"
 
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/apperror"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
 	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/validation"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	if errorsMap := validation.CustomErrorMessages(err); len(errorsMap) > 0 {
+	if errorsMap := validation.CustomErrorMessages(err, validation.Locale(c)); len(errorsMap) > 0 {
+		if response.WantsProblem(c) {
+			p := response.NewProblem(c, response.ProblemValidation, "One or more fields are invalid")
+			p.Extensions = map[string]any{"errors": errorsMap}
+			return response.WriteProblem(c, p)
+		}
 		return response.Error(c, fiber.StatusBadRequest, "Bad Request", errorsMap)
 	}
 
-	var fiberErr *fiber.Error
-	if errors.As(err, &fiberErr) {
-		return response.Error(c, fiberErr.Code, fiberErr.Message, nil)
+	status, message, meta, problemType := classify(err)
+	if appErr, ok := apperror.As(apperror.From(err)); ok && status >= fiber.StatusInternalServerError && appErr.Err != nil {
+		Log.WithContext(c.Context()).Errorf("%s %s: %+v", c.Method(), c.OriginalURL(), err)
+	}
+
+	if status == fiber.StatusUnsupportedMediaType && len(c.Response().Header.Peek(fiber.HeaderAccept)) == 0 {
+		// RFC 9110 15.5.16, media type yang bisa dikirim client
+		c.Set(fiber.HeaderAccept, strings.Join(response.BodyTypes, ", "))
+	}
+
+	if !response.WantsProblem(c) {
+		return response.Error(c, status, message, meta["errors"])
+	}
+
+	detail := message
+	if status >= fiber.StatusInternalServerError || detail == problemType.Title {
+		// jangan bocorkan detail error internal ke client
+		detail = ""
+	}
+	p := response.NewProblem(c, problemType, detail)
+	p.Extensions = meta
+	return response.WriteProblem(c, p)
+}
+
+// ErrorStatus is the status ErrorHandler responds with for err, for
+// middleware that needs it before Fiber renders the error (access log,
+// rate limit).
+func ErrorStatus(err error) int {
+	var validationErrors validator.ValidationErrors
+	var bindErrors validation.BindErrors
+	if errors.As(err, &validationErrors) || errors.As(err, &bindErrors) {
+		return fiber.StatusBadRequest
 	}
+	status, _, _, _ := classify(err)
+	return status
+}
+
+// classify maps err to its response, unknown errors are 500.
+func classify(err error) (int, string, map[string]any, response.ProblemType) {
+	status, message := fiber.StatusInternalServerError, "Internal Server Error"
+	var meta map[string]any
+	problemType := response.ProblemInternal
 
-	return response.Error(c, fiber.StatusInternalServerError, "Internal Server Error", nil)
+	var fiberErr *fiber.Error
+	var coder response.Coder
+	if appErr, ok := apperror.As(apperror.From(err)); ok {
+		status, message, meta = appErr.Status(), appErr.Message, appErr.Meta
+		problemType = response.ProblemForStatus(status)
+		if t, ok := response.LookupProblem(appErr.Code); ok {
+			problemType = t
+		}
+	} else if errors.As(err, &fiberErr) {
+		status, message = fiberErr.Code, fiberErr.Message
+		problemType = response.ProblemForStatus(status)
+	} else if errors.As(err, &coder) {
+		if t, ok := response.LookupProblem(coder.ErrorCode()); ok {
+			problemType, status, message = t, t.Status, err.Error()
+		}
+	}
+	return status, message, meta, problemType
 }
 
 func NotFoundHandler(c *fiber.Ctx) error {
-	return response.Error(c, fiber.StatusNotFound, "Endpoint Not Found", nil)
+	return fiber.NewError(fiber.StatusNotFound, "Endpoint Not Found")
+}
+
+// ProblemTypesHandler lists the problem type registry, GET /:code returns one
+// entry so every type URI resolves to its documentation.
+func ProblemTypesHandler(c *fiber.Ctx) error {
+	if code := c.Params("code"); code != "" {
+		t, ok := response.LookupProblem(code)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "Problem type not found")
+		}
+		return c.Status(fiber.StatusOK).
+			JSON(response.Success{
+				Code:    fiber.StatusOK,
+				Status:  "success",
+				Message: "Get problem type successfully",
+				Data:    t,
+			})
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.Success{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Get problem types successfully",
+			Data:    response.ProblemTypes(),
+		})
 }
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"database/sql/driver"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+// ID lists the primary key types supported by repository.BaseRepository.
+type ID interface {
+	uint | uint64 | int | int64 | uuid.UUID | ULID
+}
+
+// ULID is stored in its 26 character text form (CHAR(26) column),
+// ulid.ULID itself would be written as 16 raw bytes.
+type ULID struct {
+	ulid.ULID
+}
+
+func NewULID() ULID {
+	return ULID{ulid.Make()}
+}
+
+func (id ULID) Value() (driver.Value, error) {
+	return id.String(), nil
+}
+
+// NewUUID returns a time ordered UUIDv7, friendlier to btree indexes than v4.
+func NewUUID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
@@ -0,0 +1,155 @@
+// Package logger holds the application logger, utils.Log. It only depends on
+// requestid so packages below utils (response, validation) can log with it.
+package logger
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/requestid"
+
+	"github.com/sirupsen/logrus"
+)
+
+type CustomFormatter struct {
+	logrus.TextFormatter
+}
+
+var Log *logrus.Logger
+
+func init() {
+	Log = logrus.New()
+
+	// Set logger to use the custom text formatter
+	Log.SetFormatter(&redactFormatter{Formatter: textFormatter()})
+
+	Log.SetOutput(os.Stdout)
+	Log.AddHook(contextHook{})
+}
+
+// Setup applies LOG_FORMAT ("json" or "text") and LOG_LEVEL to Log, an
+// unknown level falls back to info.
+func Setup(format, level string) {
+	lvl := logrus.InfoLevel
+	if level != "" {
+		parsed, err := logrus.ParseLevel(level)
+		if err != nil {
+			Log.Warnf("Invalid LOG_LEVEL %q, using info", level)
+		} else {
+			lvl = parsed
+		}
+	}
+
+	var formatter logrus.Formatter = textFormatter()
+	if format == "json" {
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap:        logrus.FieldMap{logrus.FieldKeyMsg: "message"},
+		}
+	}
+
+	Log.SetLevel(lvl)
+	Log.SetFormatter(&redactFormatter{Formatter: formatter})
+}
+
+func textFormatter() logrus.Formatter {
+	return &CustomFormatter{
+		TextFormatter: logrus.TextFormatter{
+			TimestampFormat: "15:04:05.000",
+			FullTimestamp:   true,
+			ForceColors:     true,
+		},
+	}
+}
+
+// contextHook adds the request id and the authenticated user id to entries
+// logged with a request context, ex: s.Log.WithContext(c.Context()).Errorf(...).
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (contextHook) Fire(e *logrus.Entry) error {
+	if e.Context == nil {
+		return nil
+	}
+	if id := requestid.From(e.Context); id != "" {
+		e.Data["request_id"] = id
+	}
+	// c.Locals("user") dari middleware.Auth
+	if user, ok := e.Context.Value("user").(interface{ LogUserID() string }); ok {
+		e.Data["user_id"] = user.LogUserID()
+	}
+	return nil
+}
+
+// Redacted replaces the value of sensitive fields.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case insensitively against field names, so
+// "new_password", "refresh_token" or "X-Api-Key" are redacted too.
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie", "api_key", "apikey", "api-key"}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactFormatter formats a copy of the entry with sensitive fields (and
+// sensitive keys of map / http.Header fields) redacted.
+type redactFormatter struct {
+	logrus.Formatter
+}
+
+func (f *redactFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(e.Data))
+	for k, v := range e.Data {
+		data[k] = redact(k, v)
+	}
+	// entry asli bisa dipakai hook lain, jangan diubah
+	clone := *e
+	clone.Data = data
+	return f.Formatter.Format(&clone)
+}
+
+func redact(key string, value any) any {
+	if sensitive(key) {
+		return Redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = redact(k, item)
+		}
+		return m
+	case map[string]string:
+		m := make(map[string]string, len(v))
+		for k, item := range v {
+			if sensitive(k) {
+				item = Redacted
+			}
+			m[k] = item
+		}
+		return m
+	case http.Header:
+		h := make(http.Header, len(v))
+		for k, items := range v {
+			if sensitive(k) {
+				items = []string{Redacted}
+			}
+			h[k] = items
+		}
+		return h
+	}
+	return value
+}
@@ -1,28 +1,17 @@
 package utils
 
 import (
-	"os"
-
-	"github.com/sirupsen/logrus"
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/logger"
 )
 
-type CustomFormatter struct {
-	logrus.TextFormatter
-}
-
-var Log *logrus.Logger
-
-func init() {
-	Log = logrus.New()
+type CustomFormatter = logger.CustomFormatter
 
-	// Set logger to use the custom text formatter
-	Log.SetFormatter(&CustomFormatter{
-		TextFormatter: logrus.TextFormatter{
-			TimestampFormat: "15:04:05.000",
-			FullTimestamp:   true,
-			ForceColors:     true,
-		},
-	})
+// Log is the application logger. It is defined in utils/logger so that
+// response and validation, which utils imports, can use it as well.
+var Log = logger.Log
 
-	Log.SetOutput(os.Stdout)
+// SetupLog applies LOG_FORMAT ("json" or "text") and LOG_LEVEL to Log, an
+// unknown level falls back to info.
+func SetupLog(format, level string) {
+	logger.Setup(format, level)
 }
@@ -1,22 +1,100 @@
 package utils
 
-import "encoding/json"
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
 
-type NullString struct {
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/validation"
+)
+
+// Nullable is a tri-state field for PATCH bodies:
+//
+//	absent        -> Set false           (leave the column alone)
+//	"key": null   -> Set true, Valid false (clear the column)
+//	"key": value  -> Set true, Valid true
+//
+// It is a driver.Valuer, so it can go straight into a PatchOne map:
+//
+//	if req.AvatarURL.Set {
+//		updates["avatar_url"] = req.AvatarURL
+//	}
+//
+// Validator tags apply to V and are skipped when the value is absent or null.
+type Nullable[T any] struct {
+	V     T
+	Valid bool
 	Set   bool
-	Value *string
 }
 
-func (ns *NullString) UnmarshalJSON(b []byte) error {
-	ns.Set = true
+type NullString = Nullable[string]
+
+func NewNullable[T any](v T) Nullable[T] {
+	return Nullable[T]{V: v, Valid: true, Set: true}
+}
+
+func (n Nullable[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	return &n.V
+}
+
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	n.Set = true
 	if string(b) == "null" {
-		ns.Value = nil
+		var zero T
+		n.V, n.Valid = zero, false
 		return nil
 	}
-	var s string
-	if err := json.Unmarshal(b, &s); err != nil {
+	if err := json.Unmarshal(b, &n.V); err != nil {
 		return err
 	}
-	ns.Value = &s
+	n.Valid = true
 	return nil
 }
+
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+func (n Nullable[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	// int, uint, ... -> int64, driver.Value hanya kenal tipe dasar
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+func (n *Nullable[T]) Scan(src any) error {
+	var v sql.Null[T]
+	if err := v.Scan(src); err != nil {
+		return err
+	}
+	n.V, n.Valid, n.Set = v.V, v.Valid, true
+	return nil
+}
+
+// ValidationValue is what validator tags see, nil makes omitempty skip it.
+func (n Nullable[T]) ValidationValue() any {
+	if !n.Valid {
+		return nil
+	}
+	return n.V
+}
+
+// RegisterNullable makes validator tags see V of Nullable[T], call it from
+// the init of every validations package for each T its structs use:
+//
+//	func init() {
+//		utils.RegisterNullable[string]()
+//	}
+func RegisterNullable[T any]() {
+	validation.RegisterCustomType(func(field reflect.Value) any {
+		return field.Interface().(Nullable[T]).ValidationValue()
+	}, Nullable[T]{})
+}
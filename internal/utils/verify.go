@@ -3,43 +3,56 @@ package utils
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 func VerifyToken(tokenStr, secret, tokenType string) (uint, error) {
+	userID, _, err := VerifyTokenIssuedAt(tokenStr, secret, tokenType)
+	return userID, err
+}
+
+// VerifyTokenIssuedAt is VerifyToken also returning the iat claim, zero when
+// the token has none.
+func VerifyTokenIssuedAt(tokenStr, secret, tokenType string) (uint, time.Time, error) {
 	token, err := jwt.Parse(tokenStr, func(_ *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
 	})
 	if err != nil || !token.Valid {
-		return 0, err
+		return 0, time.Time{}, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return 0, errors.New("invalid token claims")
+		return 0, time.Time{}, errors.New("invalid token claims")
 	}
 
 	jwtType, ok := claims["type"].(string)
 	if !ok || jwtType != tokenType {
-		return 0, errors.New("invalid token type")
+		return 0, time.Time{}, errors.New("invalid token type")
 	}
 
 	sub, ok := claims["sub"]
 	if !ok {
-		return 0, errors.New("invalid token sub")
+		return 0, time.Time{}, errors.New("invalid token sub")
+	}
+
+	var issuedAt time.Time
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		issuedAt = iat.Time
 	}
 
 	switch v := sub.(type) {
 	case float64:
-		return uint(v), nil
+		return uint(v), issuedAt, nil
 	case string:
 		id, err := strconv.Atoi(v)
 		if err != nil {
-			return 0, errors.New("invalid sub format")
+			return 0, time.Time{}, errors.New("invalid sub format")
 		}
-		return uint(id), nil
+		return uint(id), issuedAt, nil
 	default:
-		return 0, errors.New("unsupported sub type")
+		return 0, time.Time{}, errors.New("unsupported sub type")
 	}
 }
@@ -0,0 +1,242 @@
+package validation
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Normalizer is called by Bind after filling and before validating, ex: to
+// lowercase an email that unique=users.email compares as is.
+type Normalizer interface {
+	Normalize()
+}
+
+// BindError is a value that could not be converted to its field type.
+type BindError struct {
+	Field string // param / query / header name
+	Tag   string // message key, ex: number
+}
+
+// BindErrors is returned by Bind, CustomErrorMessages translates it like
+// validator errors.
+type BindErrors []BindError
+
+func (e BindErrors) Error() string {
+	fields := make([]string, len(e))
+	for i, err := range e {
+		fields[i] = err.Field
+	}
+	return "invalid value for " + strings.Join(fields, ", ")
+}
+
+// Bind fills a T from the request and validates it with the shared validator:
+//
+//	type Query struct {
+//		ID     uint     `param:"id"`
+//		Page   int      `query:"page" default:"1" validate:"min=1"`
+//		Tags   []string `query:"tags"` // ?tags=a,b or ?tags=a&tags=b
+//		Tenant string   `header:"X-Tenant-ID"`
+//		Name   string   `json:"name" validate:"required_strict"`
+//	}
+//
+//	req, err := validation.Bind[Query](c)
+//
+// json / form fields come from c.BodyParser (MessagePack and CBOR bodies
+// arrive as JSON, see middleware.Negotiate). default applies to fields the
+// request left empty. Conversion and validation errors both render as the
+// field -> message map of CustomErrorMessages.
+func Bind[T any](c *fiber.Ctx) (*T, error) {
+	dst := new(T)
+
+	// T tanpa field json / form (ex: hanya param) tidak membaca body,
+	// body PATCH dibaca oleh patch.Bind
+	if len(c.Body()) > 0 && readsBody(reflect.TypeFor[T]()) {
+		if err := c.BodyParser(dst); errors.Is(err, fiber.ErrUnprocessableEntity) {
+			// Content-Type yang tidak dikenal BodyParser
+			return nil, fiber.NewError(fiber.StatusUnsupportedMediaType, "Use "+strings.Join(response.BodyTypes, ", "))
+		} else if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	var errs BindErrors
+	fill(c, reflect.ValueOf(dst).Elem(), &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if n, ok := any(dst).(Normalizer); ok {
+		n.Normalize()
+	}
+
+	validate, err := Validator()
+	if err != nil {
+		return nil, err
+	}
+	if err := StructCtx(c.Context(), validate, dst); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// readsBody reports whether t has fields filled from the body.
+func readsBody(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag == "" {
+			if readsBody(f.Type) {
+				return true
+			}
+			continue
+		}
+		for _, key := range []string{"param", "query", "header"} {
+			if _, ok := f.Tag.Lookup(key); ok {
+				goto next
+			}
+		}
+		if f.IsExported() {
+			return true
+		}
+	next:
+	}
+	return false
+}
+
+func fill(c *fiber.Ctx, v reflect.Value, errs *BindErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		field := v.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag == "" {
+			fill(c, field, errs)
+			continue
+		}
+
+		name, values := lookup(c, f)
+		if values == nil {
+			def, ok := f.Tag.Lookup("default")
+			if !ok || !field.IsZero() {
+				continue
+			}
+			values = []string{def}
+		}
+		if name == "" {
+			name, _, _ = strings.Cut(f.Tag.Get("json"), ",")
+		}
+
+		if tag := set(field, values); tag != "" {
+			*errs = append(*errs, BindError{Field: name, Tag: tag})
+		}
+	}
+}
+
+// lookup returns the raw values of f, nil when the request does not have it.
+func lookup(c *fiber.Ctx, f reflect.StructField) (string, []string) {
+	if name := f.Tag.Get("param"); name != "" {
+		if v := c.Params(name); v != "" {
+			return name, []string{v}
+		}
+		return name, nil
+	}
+	if name := f.Tag.Get("query"); name != "" {
+		var values []string
+		for _, v := range c.Context().QueryArgs().PeekMulti(name) {
+			// ?page= sama dengan tidak dikirim
+			if len(v) > 0 {
+				values = append(values, string(v))
+			}
+		}
+		return name, values
+	}
+	if name := f.Tag.Get("header"); name != "" {
+		if v := c.Get(name); v != "" {
+			return name, []string{v}
+		}
+		return name, nil
+	}
+	return "", nil
+}
+
+// set converts values into field, it returns the message key on failure.
+func set(field reflect.Value, values []string) string {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if tag := set(elem.Elem(), values); tag != "" {
+			return tag
+		}
+		field.Set(elem)
+		return ""
+	}
+
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		var items []string
+		for _, v := range values {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if tag := set(slice.Index(i), []string{item}); tag != "" {
+				return tag
+			}
+		}
+		field.Set(slice)
+		return ""
+	}
+
+	raw := values[len(values)-1]
+
+	// uuid.UUID, utils.ULID, time.Time (RFC 3339), ...
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(raw)); err != nil {
+			return "invalid"
+		}
+		return ""
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "boolean"
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return "number"
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return "number"
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return "number"
+		}
+		field.SetFloat(n)
+	default:
+		return "invalid"
+	}
+	return ""
+}
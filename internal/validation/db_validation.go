@@ -0,0 +1,223 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/hafizhproject45/Golang-Boilerplate.git/internal/utils/logger"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+)
+
+// database used by unique / exists, set once at startup with UseDB
+var (
+	dbMu sync.RWMutex
+	db   *gorm.DB
+)
+
+// UseDB enables the database validators:
+//
+//	Email   string `validate:"unique=users.email"`              // no row has this value
+//	Email   string `validate:"unique_except_self=users.email"`  // same, ignoring the row being updated (see WithSelf)
+//	Emails  []string `validate:"unique=users.email"`            // no row has any value, one query for the slice
+//	RoleID  uint   `validate:"exists=roles.id"`                 // a row has this value
+//	RoleIDs []uint `validate:"exists=roles.id"`                 // every value exists, one query for the slice
+//
+// Queries run on the primary with the context given to StructCtx. Soft
+// deleted rows (deleted_at) are ignored. A failed query fails the field,
+// validate with StructCtx of this package to get the query error instead.
+func UseDB(conn *gorm.DB) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	db = conn
+}
+
+type selfKey struct{}
+
+// WithSelf stores the id of the row being updated for unique_except_self:
+//
+//	validation.StructCtx(validation.WithSelf(c.Context(), id), s.Validate, req)
+func WithSelf(ctx context.Context, id any) context.Context {
+	return context.WithValue(ctx, selfKey{}, id)
+}
+
+// Self is WithSelf for the request, Bind and patch.Bind validate with
+// c.Context() which reads it from the locals:
+//
+//	validation.Self(c, params.ID)
+//	err = patch.Bind(c, req, current)
+func Self(c *fiber.Ctx, id any) {
+	c.Locals(selfKey{}, id)
+}
+
+type dbErrorKey struct{}
+
+// StructCtx validates v with validate. When a database validator could not
+// run its query that error is returned, not a "taken" / "does not exist"
+// message the client cannot act on.
+func StructCtx(ctx context.Context, validate *validator.Validate, v any) error {
+	var dbErr error
+	err := validate.StructCtx(context.WithValue(ctx, dbErrorKey{}, &dbErr), v)
+	if dbErr != nil {
+		return dbErr
+	}
+	return err
+}
+
+// queryFailed fails the field (closed) and keeps the first error for StructCtx.
+func queryFailed(ctx context.Context, fl validator.FieldLevel, err error) bool {
+	err = fmt.Errorf("validation %s=%s: %w", fl.GetTag(), fl.Param(), err)
+	logger.Log.WithContext(ctx).Errorf("%+v", err)
+	if dbErr, ok := ctx.Value(dbErrorKey{}).(*error); ok && *dbErr == nil {
+		*dbErr = err
+	}
+	return false
+}
+
+func Unique(ctx context.Context, fl validator.FieldLevel) bool {
+	return checkUnique(ctx, fl, false)
+}
+
+func UniqueExceptSelf(ctx context.Context, fl validator.FieldLevel) bool {
+	return checkUnique(ctx, fl, true)
+}
+
+// checkUnique counts rows having any of the values, one query for a slice.
+func checkUnique(ctx context.Context, fl validator.FieldLevel, exceptSelf bool) bool {
+	table, column, ok := tableColumn(fl.Param())
+	values := fieldValues(fl.Field())
+	if !ok || len(values) == 0 {
+		return true
+	}
+
+	q, err := query(ctx, table)
+	if err != nil {
+		return queryFailed(ctx, fl, err)
+	}
+	if q == nil {
+		return true
+	}
+	q = q.Where(clause.IN{Column: clause.Column{Name: column}, Values: values})
+	if id := ctx.Value(selfKey{}); exceptSelf && id != nil {
+		q = q.Where(clause.Neq{Column: clause.Column{Name: "id"}, Value: id})
+	}
+
+	var found int64
+	if err := q.Count(&found).Error; err != nil {
+		return queryFailed(ctx, fl, err)
+	}
+	return found == 0
+}
+
+// Exists counts the distinct values found, one query for a slice.
+func Exists(ctx context.Context, fl validator.FieldLevel) bool {
+	table, column, ok := tableColumn(fl.Param())
+	values := fieldValues(fl.Field())
+	if !ok || len(values) == 0 {
+		return true
+	}
+
+	q, err := query(ctx, table)
+	if err != nil {
+		return queryFailed(ctx, fl, err)
+	}
+	if q == nil {
+		return true
+	}
+
+	var found int64
+	err = q.Where(clause.IN{Column: clause.Column{Name: column}, Values: values}).
+		Distinct(column).
+		Count(&found).Error
+	if err != nil {
+		return queryFailed(ctx, fl, err)
+	}
+	return found == int64(len(values))
+}
+
+// fieldValues returns the distinct non-empty values of a scalar or slice field.
+func fieldValues(field reflect.Value) []any {
+	values := []any{}
+	if field.Kind() == reflect.Slice || field.Kind() == reflect.Array {
+		seen := map[any]struct{}{}
+		for i := 0; i < field.Len(); i++ {
+			v, present := scalar(field.Index(i))
+			if _, dup := seen[v]; present && !dup {
+				seen[v] = struct{}{}
+				values = append(values, v)
+			}
+		}
+	} else if v, present := scalar(field); present {
+		values = append(values, v)
+	}
+	return values
+}
+
+var softDelete sync.Map // table -> has deleted_at
+
+// query returns nil when UseDB was not called.
+func query(ctx context.Context, table string) (*gorm.DB, error) {
+	dbMu.RLock()
+	conn := db
+	dbMu.RUnlock()
+	if conn == nil {
+		return nil, nil
+	}
+
+	// primary, replica bisa tertinggal dari insert barusan
+	q := conn.WithContext(ctx).Clauses(dbresolver.Write).Table(table)
+
+	softDeleted, err := hasSoftDelete(ctx, conn, table)
+	if err != nil {
+		return nil, err
+	}
+	if softDeleted {
+		q = q.Where("deleted_at IS NULL")
+	}
+	return q, nil
+}
+
+func hasSoftDelete(ctx context.Context, conn *gorm.DB, table string) (bool, error) {
+	if v, ok := softDelete.Load(table); ok {
+		return v.(bool), nil
+	}
+
+	var n int64
+	err := conn.WithContext(ctx).Raw(
+		"SELECT count(*) FROM information_schema.columns WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_name = 'deleted_at'",
+		table,
+	).Scan(&n).Error
+	if err != nil {
+		// jangan di-cache, coba lagi di request berikutnya
+		return false, err
+	}
+	softDelete.Store(table, n > 0)
+	return n > 0, nil
+}
+
+// tableColumn splits "users.email".
+func tableColumn(param string) (string, string, bool) {
+	table, column, ok := strings.Cut(param, ".")
+	return table, column, ok && table != "" && column != ""
+}
+
+// scalar dereferences pointers, empty values are not checked (use required).
+func scalar(v reflect.Value) (any, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.IsZero() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
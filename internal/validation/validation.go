@@ -3,73 +3,312 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+	"github.com/gofiber/fiber/v2"
 )
 
-var customMessages = map[string]string{
-	"required":         "Field %s is required",
-	"required_strict":  "Field %s is required and cannot be null or empty",
-	"omitempty_strict": "Field %s cannot be null or empty when provided",
+// DefaultLocale is used when neither the request nor the user picks a supported one.
+const DefaultLocale = "en"
 
-	"email":    "Invalid email address for field %s",
-	"min":      "Field %s must have a minimum length of %s characters",
-	"max":      "Field %s must have a maximum length of %s characters",
-	"len":      "Field %s must be exactly %s characters long",
-	"number":   "Field %s must be a number",
-	"positive": "Field %s must be a positive number",
-	"alphanum": "Field %s must contain only alphanumeric characters",
-	"oneof":    "Invalid value for field %s",
-	"password": "Field %s must be at least 8 characters, contain uppercase, lowercase, number, and special character",
+// Locales are the supported message bundles, DefaultLocale first.
+var Locales = []string{"en", "id"}
+
+var uni = ut.New(en.New(), en.New(), id.New())
+
+var defaultTranslations = map[string]func(*validator.Validate, ut.Translator) error{
+	"en": enTranslations.RegisterDefaultTranslations,
+	"id": idTranslations.RegisterDefaultTranslations,
+}
+
+// customMessages override the built-in translations, {0} is the field and {1} the tag param.
+var customMessages = map[string]map[string]string{
+	"en": {
+		"required":         "Field {0} is required",
+		"required_strict":  "Field {0} is required and cannot be null or empty",
+		"omitempty_strict": "Field {0} cannot be null or empty when provided",
+
+		"email": "Invalid email address for field {0}",
+		"min":   "Field {0} must have a minimum length of {1} characters",
+		"max":   "Field {0} must have a maximum length of {1} characters",
+		"len":   "Field {0} must be exactly {1} characters long",
+
+		"min_number": "Field {0} must be at least {1}",
+		"max_number": "Field {0} must be at most {1}",
+
+		"number":   "Field {0} must be a number",
+		"boolean":  "Field {0} must be true or false",
+		"invalid":  "Field {0} has an invalid value",
+		"positive": "Field {0} must be a positive number",
+		"alphanum": "Field {0} must contain only alphanumeric characters",
+		"oneof":    "Invalid value for field {0}",
+		"nefield":  "Field {0} must be different from {1}",
+		"json":     "Field {0} must be valid JSON",
+		"password": "Field {0} must be at least 8 characters, contain uppercase, lowercase, number, and special character",
+
+		"http_url":           "Field {0} must be a valid http(s) URL",
+		"timezone":           "Field {0} must be a valid IANA timezone, ex: Asia/Jakarta",
+		"bcp47_language_tag": "Field {0} must be a valid language tag, ex: en or id-ID",
+
+		"unique":             "Field {0} is already taken",
+		"unique_except_self": "Field {0} is already taken",
+		"exists":             "Field {0} refers to a record that does not exist",
+	},
+	"id": {
+		"required":         "Field {0} wajib diisi",
+		"required_strict":  "Field {0} wajib diisi dan tidak boleh null atau kosong",
+		"omitempty_strict": "Field {0} tidak boleh null atau kosong jika dikirim",
+
+		"email": "Alamat email pada field {0} tidak valid",
+		"min":   "Field {0} minimal {1} karakter",
+		"max":   "Field {0} maksimal {1} karakter",
+		"len":   "Field {0} harus tepat {1} karakter",
+
+		"min_number": "Field {0} minimal {1}",
+		"max_number": "Field {0} maksimal {1}",
+
+		"number":   "Field {0} harus berupa angka",
+		"boolean":  "Field {0} harus berupa true atau false",
+		"invalid":  "Nilai field {0} tidak sesuai format",
+		"positive": "Field {0} harus berupa angka positif",
+		"alphanum": "Field {0} hanya boleh berisi huruf dan angka",
+		"oneof":    "Nilai field {0} tidak valid",
+		"nefield":  "Field {0} harus berbeda dari {1}",
+		"json":     "Field {0} harus berupa JSON yang valid",
+		"password": "Field {0} minimal 8 karakter, mengandung huruf besar, huruf kecil, angka, dan karakter khusus",
+
+		"http_url":           "Field {0} harus berupa URL http(s) yang valid",
+		"timezone":           "Field {0} harus berupa zona waktu IANA yang valid, contoh: Asia/Jakarta",
+		"bcp47_language_tag": "Field {0} harus berupa kode bahasa yang valid, contoh: en atau id-ID",
+
+		"unique":             "Field {0} sudah digunakan",
+		"unique_except_self": "Field {0} sudah digunakan",
+		"exists":             "Field {0} merujuk ke data yang tidak ada",
+	},
 }
 
-func CustomErrorMessages(err error) map[string]string {
+// CustomErrorMessages returns the validation errors in err keyed by JSON
+// path (ex: name, items[2].qty), translated to locale.
+func CustomErrorMessages(err error, locale string) map[string]string {
 	var validationErrors validator.ValidationErrors
 	if errors.As(err, &validationErrors) {
-		return generateErrorMessages(validationErrors)
+		return generateErrorMessages(validationErrors, translator(locale))
+	}
+	var bindErrors BindErrors
+	if errors.As(err, &bindErrors) {
+		return bindErrorMessages(bindErrors, translator(locale))
 	}
 	return nil
 }
 
-func generateErrorMessages(validationErrors validator.ValidationErrors) map[string]string {
+func bindErrorMessages(bindErrors BindErrors, trans ut.Translator) map[string]string {
 	errorsMap := make(map[string]string)
-	for _, err := range validationErrors {
-		fieldName := err.StructNamespace()
-		tag := err.Tag()
-
-		customMessage := customMessages[tag]
-		if customMessage != "" {
-			errorsMap[fieldName] = formatErrorMessage(customMessage, err, tag)
-		} else {
-			errorsMap[fieldName] = defaultErrorMessage(err)
+	for _, err := range bindErrors {
+		message, tErr := trans.T(err.Tag, err.Field)
+		if tErr != nil {
+			message = "Field " + err.Field + " has an invalid value"
 		}
+		errorsMap[err.Field] = message
 	}
 	return errorsMap
 }
 
-func formatErrorMessage(customMessage string, err validator.FieldError, tag string) string {
-	if tag == "min" || tag == "max" || tag == "len" {
-		return fmt.Sprintf(customMessage, err.Field(), err.Param())
+func generateErrorMessages(validationErrors validator.ValidationErrors, trans ut.Translator) map[string]string {
+	errorsMap := make(map[string]string)
+	for _, err := range validationErrors {
+		message := err.Translate(trans)
+		if message == err.Error() {
+			// tag tanpa terjemahan
+			message = defaultErrorMessage(err)
+		}
+		errorsMap[jsonPath(err)] = message
 	}
-	return fmt.Sprintf(customMessage, err.Field())
+	return errorsMap
 }
 
 func defaultErrorMessage(err validator.FieldError) string {
 	return fmt.Sprintf("Field validation for '%s' failed on the '%s' tag", err.Field(), err.Tag())
 }
 
-func Validator() *validator.Validate {
+// jsonPath drops the struct name from the namespace, ex: Create.items[2].qty -> items[2].qty
+func jsonPath(err validator.FieldError) string {
+	ns := err.Namespace()
+	if _, path, ok := strings.Cut(ns, "."); ok {
+		return path
+	}
+	return ns
+}
+
+func translator(locale string) ut.Translator {
+	trans, _ := uni.FindTranslator(locale, strings.SplitN(locale, "-", 2)[0], DefaultLocale)
+	return trans
+}
+
+// Locale picks the message locale of the request: Accept-Language first,
+// then the locale saved on the authenticated user.
+func Locale(c *fiber.Ctx) string {
+	if header := c.Get(fiber.HeaderAcceptLanguage); header != "" && header != "*" {
+		if locale := c.AcceptsLanguages(Locales...); locale != "" {
+			return locale
+		}
+	}
+	if user, ok := c.Locals("user").(interface{ PreferredLocale() string }); ok {
+		return user.PreferredLocale()
+	}
+	return DefaultLocale
+}
+
+var (
+	validatorOnce = sync.OnceValues(newValidator)
+
+	customTypes []customType
+	built       atomic.Bool
+
+	// fieldNames maps Go field names to the names clients send, filled by the
+	// tag name func so *field params (ex: nefield=CurrentPassword) read like
+	// the field itself. A Go name used with different tags maps to "".
+	fieldNames sync.Map
+)
+
+type customType struct {
+	fn    validator.CustomTypeFunc
+	types []any
+}
+
+// RegisterCustomType makes tags validate what fn returns instead of the
+// field itself (ex: utils.RegisterNullable). Call it from the init of the
+// package declaring the request structs, it panics once Validator was built.
+func RegisterCustomType(fn validator.CustomTypeFunc, types ...any) {
+	if built.Load() {
+		panic("validation: RegisterCustomType called after Validator")
+	}
+	customTypes = append(customTypes, customType{fn: fn, types: types})
+}
+
+// Validator returns the shared validator, translations live in a global
+// translator so it is only built once.
+func Validator() (*validator.Validate, error) {
+	return validatorOnce()
+}
+
+func newValidator() (*validator.Validate, error) {
+	built.Store(true)
 	validate := validator.New()
 
+	// field names follow what the client sent, not the Go struct
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := tagName(f)
+		if name != "" {
+			if known, loaded := fieldNames.LoadOrStore(f.Name, name); loaded && known != name {
+				fieldNames.Store(f.Name, "")
+			}
+		}
+		return name
+	})
+
+	for _, t := range customTypes {
+		validate.RegisterCustomTypeFunc(t.fn, t.types...)
+	}
+
 	if err := validate.RegisterValidation("password", Password); err != nil {
-		return nil
+		return nil, err
 	}
 	if err := validate.RegisterValidation("required_strict", RequiredStrict); err != nil {
-		return nil
+		return nil, err
 	}
 	if err := validate.RegisterValidation("omitempty_strict", OmitemptyStrict); err != nil {
-		return nil
+		return nil, err
+	}
+
+	// database validators, see UseDB
+	if err := validate.RegisterValidationCtx("unique", Unique); err != nil {
+		return nil, err
+	}
+	if err := validate.RegisterValidationCtx("unique_except_self", UniqueExceptSelf); err != nil {
+		return nil, err
+	}
+	if err := validate.RegisterValidationCtx("exists", Exists); err != nil {
+		return nil, err
+	}
+
+	if err := registerTranslations(validate); err != nil {
+		return nil, err
+	}
+
+	return validate, nil
+}
+
+// tagName is the name of f in the request, "" keeps the Go name.
+func tagName(f reflect.StructField) string {
+	for _, key := range []string{"json", "param", "query", "header", "form", "params"} {
+		name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func registerTranslations(validate *validator.Validate) error {
+	for _, locale := range Locales {
+		trans, _ := uni.GetTranslator(locale)
+		if err := defaultTranslations[locale](validate, trans); err != nil {
+			return err
+		}
+
+		for tag, message := range customMessages[locale] {
+			var err error
+			if strings.HasSuffix(tag, "_number") {
+				// variant of min / max, picked by translate
+				err = trans.Add(tag, message, true)
+			} else {
+				err = validate.RegisterTranslation(tag, trans,
+					func(t ut.Translator) error { return t.Add(tag, message, true) },
+					translate,
+				)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func translate(t ut.Translator, err validator.FieldError) string {
+	param := err.Param()
+	// nefield=CurrentPassword -> current_password
+	if strings.HasSuffix(err.Tag(), "field") {
+		if name, ok := fieldNames.Load(param); ok && name != "" {
+			param = name.(string)
+		}
 	}
 
-	return validate
+	key := err.Tag()
+	switch err.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if key == "min" || key == "max" {
+			key += "_number"
+		}
+	}
+
+	message, tErr := t.T(key, err.Field(), param)
+	if tErr != nil {
+		return err.Error()
+	}
+	return message
 }
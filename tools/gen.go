@@ -10,24 +10,45 @@ import (
 )
 
 type Data struct {
-	FeatName string   // input full feature (ex: "master/area")
-	Parts    []string // split parts ["master","area"]
-	Entity   string   // last ("area")
+	FeatName   string   // input full feature (ex: "master/area")
+	Parts      []string // split parts ["master","area"]
+	Entity     string   // last ("area")
+	IDStrategy string   // uint | int64 | uuid | ulid
+	IDType     string   // Go type of the primary key (ex: "uuid.UUID")
+}
+
+// strategy primary key yang didukung, value = Go type
+var idTypes = map[string]string{
+	"uint":  "uint",
+	"int64": "int64",
+	"uuid":  "uuid.UUID",
+	"ulid":  "utils.ULID",
 }
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatal("usage: make gen feat=<feature>  (ex: customer | master/area)")
+		log.Fatal("usage: make gen feat=<feature> [id=uint|int64|uuid|ulid]  (ex: customer | master/area)")
 	}
 
 	feat := os.Args[1]
 	parts := strings.Split(feat, "/")
 	entity := parts[len(parts)-1]
 
+	strategy := "uint"
+	if len(os.Args) > 2 && os.Args[2] != "" {
+		strategy = strings.ToLower(os.Args[2])
+	}
+	idType, ok := idTypes[strategy]
+	if !ok {
+		log.Fatalf("unknown id strategy %q (uint | int64 | uuid | ulid)", strategy)
+	}
+
 	d := Data{
-		FeatName: feat,
-		Parts:    parts,
-		Entity:   entity,
+		FeatName:   feat,
+		Parts:      parts,
+		Entity:     entity,
+		IDStrategy: strategy,
+		IDType:     idType,
 	}
 
 	// daftar template yang mau diproses
@@ -101,6 +122,7 @@ func main() {
 					"Camel":  toCamelCase,
 					"Plural": toPlural,
 					"Kebab":  toKebab,
+					"Snake":  toSnake,
 				}).
 				ParseFiles(file.TplPath),
 		)
@@ -235,6 +257,11 @@ func toKebabPath(parts []string) string {
 	return filepath.Join(toKebabParts(parts)...)
 }
 
+// snake_case (untuk error code)
+func toSnake(s string) string {
+	return strings.ReplaceAll(toKebab(s), "-", "_")
+}
+
 func toKebabParts(parts []string) []string {
 	var out []string
 	for _, p := range parts {